@@ -33,6 +33,13 @@ const (
 	CodeCasbinUnauthorized     = 100011
 	CodeResourceCreationFailed = 100012
 	CodeResourceExists         = 100013
+	CodeMFARequired            = 100014
+	CodeTooManyRequests        = 100015
+	CodeEmailNotVerified       = 100016
+	CodeLoginLocked            = 100017
+	CodeLastLoginMethod        = 100018
+	CodeTemplateOverride       = 100019
+	CodeRoleCycle              = 100020
 )
 
 // Status codes
@@ -115,6 +122,42 @@ func RESOURCE_EXISTS(s ...string) Response {
 	return responseTemplate(CodeResourceExists, "Resource already exists", false, s...)
 }
 
+// MFA_REQUIRED returns a response for requests lacking two-factor authentication.
+func MFA_REQUIRED(s ...string) Response {
+	return responseTemplate(CodeMFARequired, "Two-factor authentication required", true, s...)
+}
+
+// TOO_MANY_REQUESTS returns a response for rate limited requests.
+func TOO_MANY_REQUESTS(s ...string) Response {
+	return responseTemplate(CodeTooManyRequests, "Too many requests", true, s...)
+}
+
+// EMAIL_NOT_VERIFIED returns a response for users that have to verify their email first.
+func EMAIL_NOT_VERIFIED(s ...string) Response {
+	return responseTemplate(CodeEmailNotVerified, "Email not verified", true, s...)
+}
+
+// LOGIN_LOCKED returns a response for logins locked after too many failed attempts.
+func LOGIN_LOCKED(s ...string) Response {
+	return responseTemplate(CodeLoginLocked, "Login temporarily locked after too many failed attempts", true, s...)
+}
+
+// LAST_LOGIN_METHOD returns a response for changes that would leave an account without a way to log in.
+func LAST_LOGIN_METHOD(s ...string) Response {
+	return responseTemplate(CodeLastLoginMethod, "Account needs at least one way to log in", true, s...)
+}
+
+// TEMPLATE_OVERRIDE returns a response for revoking the last permission of a role overriding a System template,
+// which would restore the template instead.
+func TEMPLATE_OVERRIDE(s ...string) Response {
+	return responseTemplate(CodeTemplateOverride, "Role would inherit the System template again, reset its override instead", true, s...)
+}
+
+// ROLE_CYCLE returns a response for a parent role that inherits from the role already.
+func ROLE_CYCLE(s ...string) Response {
+	return responseTemplate(CodeRoleCycle, "Role hierarchy would contain a cycle", true, s...)
+}
+
 // STATUS_OK returns a response for successful operations.
 func STATUS_OK(s ...string) Response {
 	return responseTemplate(CodeStatusOK, "Ok", false, s...)
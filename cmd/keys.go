@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"goweb/config"
+	"goweb/keys"
+	"goweb/services"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyRingName  string
+	keyAlgorithm string
+	forceRetire  bool
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the token signing key rings",
+	Long: `Rotate the keys tokens are signed with without logging out users:
+1. generate - add a new key, it only verifies tokens until promoted
+2. promote  - sign new tokens with the key, the previous key keeps verifying its tokens
+3. retire   - remove a key once all tokens signed with it have expired
+
+Running instances pick up changes within a minute, wait that long between generate and promote.`,
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys of the key ring",
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest := loadKeyManifest()
+		ring, err := manifest.Ring(keyRingName)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to list keys")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KID\tALG\tSTATUS\tCREATED\tRETIRE AFTER")
+		for _, key := range ring.Keys {
+			retireAfter := "-"
+			if key.RetireAfter != nil {
+				retireAfter = key.RetireAfter.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Algorithm, key.Status,
+				key.CreatedAt.Format(time.RFC3339), retireAfter)
+		}
+		w.Flush()
+	},
+}
+
+var keysGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a new key, pending promotion",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.NewConfig()
+		manifest := loadKeyManifest()
+		ring, err := manifest.Ring(keyRingName)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to generate key")
+		}
+
+		// The first generated key takes over the key configured through the environment,
+		// so tokens signed with it stay valid after the switch to the key ring.
+		if len(ring.Keys) == 0 {
+			current, err := configuredKey(cfg.Auth, keyRingName)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to import configured key")
+			}
+			if err := manifest.Import(keyRingName, current, keys.StatusActive); err != nil {
+				log.Fatal().Err(err).Msg("Failed to import configured key")
+			}
+			if keyAlgorithm == "" {
+				keyAlgorithm = current.Method.Alg()
+			}
+		}
+		if keyAlgorithm == "" {
+			keyAlgorithm = activeAlgorithm(ring)
+		}
+
+		key, err := keys.GenerateKey(keyAlgorithm)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to generate key")
+		}
+		if err := manifest.Import(keyRingName, key, keys.StatusPending); err != nil {
+			log.Fatal().Err(err).Msg("Failed to store key")
+		}
+		saveKeyManifest(manifest)
+
+		fmt.Printf("Generated %s key %s for the %s ring, promote it once all instances reloaded\n",
+			keyAlgorithm, key.ID, keyRingName)
+	},
+}
+
+var keysPromoteCmd = &cobra.Command{
+	Use:   "promote <kid>",
+	Short: "Sign new tokens with the key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest := loadKeyManifest()
+		if err := manifest.Promote(keyRingName, args[0], tokenLifetime(keyRingName)+keys.ReloadInterval); err != nil {
+			log.Fatal().Err(err).Msg("Failed to promote key")
+		}
+		saveKeyManifest(manifest)
+
+		fmt.Printf("Key %s now signs %s tokens\n", args[0], keyRingName)
+	},
+}
+
+var keysRetireCmd = &cobra.Command{
+	Use:   "retire <kid>",
+	Short: "Remove a key whose tokens have expired",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest := loadKeyManifest()
+		if err := manifest.Retire(keyRingName, args[0], forceRetire); err != nil {
+			log.Fatal().Err(err).Msg("Failed to retire key")
+		}
+		saveKeyManifest(manifest)
+
+		fmt.Printf("Key %s removed from the %s ring\n", args[0], keyRingName)
+	},
+}
+
+func init() {
+	keysCmd.PersistentFlags().StringVarP(&keyRingName, "ring", "r", keys.RingAccess, "Key ring to manage (access|refresh)")
+	keysGenerateCmd.Flags().StringVarP(&keyAlgorithm, "alg", "a", "", "Signing algorithm (HS512|RS256|EdDSA), defaults to the algorithm of the active key")
+	keysRetireCmd.Flags().BoolVarP(&forceRetire, "force", "f", false, "Retire even if tokens signed with the key may still be valid")
+
+	keysCmd.AddCommand(keysListCmd, keysGenerateCmd, keysPromoteCmd, keysRetireCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+func loadKeyManifest() *keys.Manifest {
+	cfg := config.NewConfig()
+	if cfg.Auth.JWT.KeyRingDir == "" {
+		log.Fatal().Msg("JWT_KEYRING_DIR is not configured")
+	}
+
+	manifest, err := keys.LoadManifest(cfg.Auth.JWT.KeyRingDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load key ring")
+	}
+	return manifest
+}
+
+func saveKeyManifest(manifest *keys.Manifest) {
+	if err := manifest.Save(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to save key ring")
+	}
+}
+
+func configuredKey(cfg config.AuthConfig, ring string) (*keys.Key, error) {
+	switch ring {
+	case keys.RingAccess:
+		return keys.ConfiguredAccessKey(cfg)
+	case keys.RingRefresh:
+		return keys.ConfiguredRefreshKey(cfg)
+	}
+	return nil, errors.New("unknown key ring: " + ring)
+}
+
+func activeAlgorithm(ring *keys.RingManifest) string {
+	for _, key := range ring.Keys {
+		if key.Status == keys.StatusActive {
+			return key.Algorithm
+		}
+	}
+	return keys.AlgorithmHS512
+}
+
+// tokenLifetime is how long tokens signed with a replaced key remain valid
+func tokenLifetime(ring string) time.Duration {
+	if ring == keys.RingRefresh {
+		return time.Minute * services.ExpireRefreshMinutes
+	}
+	return time.Minute * services.ExpireAccessMinutes
+}
@@ -38,7 +38,7 @@ func GetDB() *gorm.DB {
 }
 
 func MigrateUp() {
-	db.AddMigrators(migrations.DatabaseTables{}, migrations.TableData{})
+	db.AddMigrators(migrations.DatabaseTables{}, migrations.TableData{}, migrations.SessionPolicies{}, migrations.APIKeyTables{}, migrations.MFATables{}, migrations.WebAuthnTables{}, migrations.EmailVerificationTables{}, migrations.IdentityTables{}, migrations.PermissionPolicies{}, migrations.UserRolePolicies{}, migrations.PermissionExplainPolicies{}, migrations.RoleTemplates{}, migrations.RoleGrantTables{})
 
 	if err := db.Migrate(GetDB()); err != nil {
 		log.Fatal().Msg("Migrate UP failed")
@@ -49,7 +49,7 @@ func MigrateUp() {
 }
 
 func MigrateDown() {
-	db.AddMigrators(migrations.DatabaseTables{}, migrations.TableData{})
+	db.AddMigrators(migrations.DatabaseTables{}, migrations.TableData{}, migrations.SessionPolicies{}, migrations.APIKeyTables{}, migrations.MFATables{}, migrations.WebAuthnTables{}, migrations.EmailVerificationTables{}, migrations.IdentityTables{}, migrations.PermissionPolicies{}, migrations.UserRolePolicies{}, migrations.PermissionExplainPolicies{}, migrations.RoleTemplates{}, migrations.RoleGrantTables{})
 
 	if err := db.MigrateDown(GetDB()); err != nil {
 		log.Fatal().Msg("Migrate DOWN failed")
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"goweb/server"
+	"goweb/services"
+	"os"
+	"text/tabwriter"
+
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var rolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "Inspect roles and their Casbin rules",
+}
+
+var rolesCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Report drift between the roles table and the Casbin rules",
+	Long: `Compare the roles table with the Casbin policies and assignments:
+  unknown_role    - rule of a role without role record in its domain or System
+  unknown_domain  - rule for a domain that does not exist
+  unknown_subject - assignment to neither a user nor an API key
+  unused_role     - role record without policies, informational
+
+Exits with status 1 if anything but unused roles was found.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		database := GetDB()
+		adapter, err := ga.NewAdapterByDBUseTableName(database, "", services.CasbinTable)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open Casbin rules")
+		}
+		enforcer, err := casbin.NewSyncedEnforcer("casbin/model.conf", adapter)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load Casbin rules")
+		}
+
+		drifts, err := services.NewRoleService(&server.Server{DB: database, Casbin: enforcer}).CheckConsistency()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to check roles")
+		}
+		if len(drifts) == 0 {
+			fmt.Println("Roles and Casbin rules are consistent")
+			return
+		}
+
+		failed := false
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tDOMAIN\tROLE\tRULE")
+		for _, drift := range drifts {
+			failed = failed || drift.Kind != services.DriftUnusedRole
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", drift.Kind, drift.Domain, drift.Role, drift.Rule)
+		}
+		w.Flush()
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rolesCmd.AddCommand(rolesCheckCmd)
+	rootCmd.AddCommand(rolesCmd)
+}
@@ -6,6 +6,7 @@ import (
 	"goweb/docs"
 	"goweb/routes"
 	"goweb/server"
+	"goweb/services"
 	"os"
 
 	"github.com/rs/zerolog/log"
@@ -43,6 +44,7 @@ func StartServer() {
 	docs.SwaggerInfo.Host = fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port)
 	app := server.NewServer(cfg)
 	routes.ConfigureRoutes(app)
+	services.StartRoleGrantSweeper(app)
 	err := app.Start(cfg.HTTP.Port)
 	if err != nil {
 		log.Fatal().Msg("Port already used")
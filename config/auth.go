@@ -1,16 +1,84 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+)
 
 type AuthConfig struct {
 	AccessSecret  string
 	RefreshSecret string
+	JWT           JWTConfig
 	Social        SocialConfig
+	WebAuthn      WebAuthnConfig
+	Lockout       LockoutConfig
+}
+
+// JWTConfig selects how access tokens are signed.
+// HS512 signs with AccessSecret, RS256 & EdDSA sign with the PEM encoded PrivateKeyFile.
+// When KeyRingDir holds a key ring manifest, its keys are used instead.
+type JWTConfig struct {
+	Algorithm      string
+	PrivateKeyFile string
+	KeyID          string
+	KeyRingDir     string
+}
+
+// WebAuthnConfig identifies this service as relying party for passkeys.
+// RPID is the domain passkeys are bound to, RPOrigins the origins allowed to run the ceremonies.
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+}
+
+// LockoutConfig limits failed password logins.
+// Every failure delays the next attempt by BackoffSeconds, doubling per failure, until MaxAttempts
+// failures of an account or IPMaxAttempts failures of an IP lock logins for LockoutMinutes.
+type LockoutConfig struct {
+	MaxAttempts    int
+	IPMaxAttempts  int
+	LockoutMinutes int
+	BackoffSeconds int
 }
 
 type SocialConfig struct {
 	Google GoogleConfig
 	GitHub GitHubConfig
+	OIDC   []OIDCConfig
+	Signup SignupConfig
+}
+
+// SignupConfig decides the domain and role of users signing up with a social or OpenID Connect login.
+// The first rule matching the domain of a verified email wins, then the provider's target, then Default.
+type SignupConfig struct {
+	Default   SignupTarget
+	Providers map[string]SignupTarget
+	Rules     []SignupRule
+}
+
+// SignupTarget is a domain by name and the role granted in it, an empty field falls back to the default
+type SignupTarget struct {
+	Domain string
+	Role   string
+}
+
+// SignupRule sends users whose email ends in @EmailDomain to Target
+type SignupRule struct {
+	EmailDomain string
+	Target      SignupTarget
+}
+
+// OIDCConfig is a generic OpenID Connect provider, served under /auth/{Name}.
+// Its endpoints and signing keys are discovered from Issuer + "/.well-known/openid-configuration".
+type OIDCConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 type GoogleConfig struct {
@@ -26,9 +94,26 @@ type GitHubConfig struct {
 }
 
 func LoadAuthConfig() AuthConfig {
-	return AuthConfig{
+	config := AuthConfig{
 		AccessSecret:  os.Getenv("ACCESS_SECRET"),
 		RefreshSecret: os.Getenv("REFRESH_SECRET"),
+		JWT: JWTConfig{
+			Algorithm:      os.Getenv("JWT_ALGORITHM"),
+			PrivateKeyFile: os.Getenv("JWT_PRIVATE_KEY_FILE"),
+			KeyID:          os.Getenv("JWT_KEY_ID"),
+			KeyRingDir:     os.Getenv("JWT_KEYRING_DIR"),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:          os.Getenv("WEBAUTHN_RP_ID"),
+			RPDisplayName: os.Getenv("WEBAUTHN_RP_NAME"),
+			RPOrigins:     splitList(os.Getenv("WEBAUTHN_RP_ORIGINS")),
+		},
+		Lockout: LockoutConfig{
+			MaxAttempts:    intOr(os.Getenv("LOGIN_MAX_ATTEMPTS"), 5),
+			IPMaxAttempts:  intOr(os.Getenv("LOGIN_IP_MAX_ATTEMPTS"), 50),
+			LockoutMinutes: intOr(os.Getenv("LOGIN_LOCKOUT_MINUTES"), 15),
+			BackoffSeconds: intOr(os.Getenv("LOGIN_BACKOFF_SECONDS"), 1),
+		},
 		Social: SocialConfig{
 			Google: GoogleConfig{
 				ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
@@ -40,6 +125,96 @@ func LoadAuthConfig() AuthConfig {
 				ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
 				RedirectURL:  os.Getenv("GITHUB_REDIRECT_URL"),
 			},
+			OIDC: loadOIDCConfigs(),
 		},
 	}
+	config.Social.Signup = loadSignupConfig(config.Social.OIDC)
+	return config
+}
+
+// loadSignupConfig reads SOCIAL_SIGNUP_DOMAIN and _ROLE, the per provider SOCIAL_SIGNUP_<PROVIDER>_DOMAIN and _ROLE
+// and SOCIAL_SIGNUP_RULES, a list of email-domain=Domain:Role entries like "acme.com=Acme:Manager"
+func loadSignupConfig(oidc []OIDCConfig) SignupConfig {
+	signup := SignupConfig{
+		Default: SignupTarget{
+			Domain: stringOr(os.Getenv("SOCIAL_SIGNUP_DOMAIN"), "System"),
+			Role:   stringOr(os.Getenv("SOCIAL_SIGNUP_ROLE"), "Operator"),
+		},
+		Providers: make(map[string]SignupTarget),
+	}
+
+	providers := []string{"google", "github"}
+	for _, provider := range oidc {
+		providers = append(providers, provider.Name)
+	}
+	for _, provider := range providers {
+		prefix := "SOCIAL_SIGNUP_" + strings.ToUpper(strings.ReplaceAll(provider, "-", "_")) + "_"
+		target := SignupTarget{Domain: os.Getenv(prefix + "DOMAIN"), Role: os.Getenv(prefix + "ROLE")}
+		if target.Domain != "" || target.Role != "" {
+			signup.Providers[provider] = target
+		}
+	}
+
+	for _, entry := range splitList(os.Getenv("SOCIAL_SIGNUP_RULES")) {
+		emailDomain, target, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		domain, role, _ := strings.Cut(target, ":")
+		signup.Rules = append(signup.Rules, SignupRule{
+			EmailDomain: strings.ToLower(strings.TrimPrefix(strings.TrimSpace(emailDomain), "@")),
+			Target:      SignupTarget{Domain: strings.TrimSpace(domain), Role: strings.TrimSpace(role)},
+		})
+	}
+	return signup
+}
+
+// loadOIDCConfigs reads the providers listed in OIDC_PROVIDERS, each configured with
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and optional _SCOPES
+func loadOIDCConfigs() []OIDCConfig {
+	var providers []OIDCConfig
+	for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		scopes := splitList(os.Getenv(prefix + "SCOPES"))
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+		providers = append(providers, OIDCConfig{
+			Name:         strings.ToLower(name),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       scopes,
+		})
+	}
+	return providers
+}
+
+// splitList splits a comma separated environment value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// stringOr returns the environment value, falling back when it is unset
+func stringOr(value, fallback string) string {
+	if value = strings.TrimSpace(value); value == "" {
+		return fallback
+	}
+	return value
+}
+
+// intOr parses a numeric environment value, falling back when it is unset or not a positive number
+func intOr(value string, fallback int) int {
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || number <= 0 {
+		return fallback
+	}
+	return number
 }
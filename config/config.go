@@ -9,6 +9,7 @@ type Config struct {
 	Auth  AuthConfig
 	DB    DBConfig
 	HTTP  HTTPConfig
+	Mail  MailConfig
 	Redis RedisConfig
 }
 
@@ -22,6 +23,7 @@ func NewConfig() *Config {
 		Auth:  LoadAuthConfig(),
 		DB:    LoadDBConfig(),
 		HTTP:  LoadHTTPConfig(),
+		Mail:  LoadMailConfig(),
 		Redis: LoadRedisConfig(),
 	}
 }
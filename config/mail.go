@@ -0,0 +1,27 @@
+package config
+
+import "os"
+
+// MailConfig selects how emails are delivered.
+// Driver is log (default), file or smtp, log & file are meant for development.
+type MailConfig struct {
+	Driver       string
+	From         string
+	FileDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+}
+
+func LoadMailConfig() MailConfig {
+	return MailConfig{
+		Driver:       os.Getenv("MAIL_DRIVER"),
+		From:         os.Getenv("MAIL_FROM"),
+		FileDir:      os.Getenv("MAIL_FILE_DIR"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     os.Getenv("SMTP_PORT"),
+		SMTPUser:     os.Getenv("SMTP_USER"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+	}
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"goweb/models"
+
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+type APIKeyTables struct{}
+
+func (APIKeyTables) Id() string {
+	return "APIKeyMigration"
+}
+
+func (APIKeyTables) Up(db *gorm.DB) {
+	db.Migrator().AutoMigrate(&models.APIKey{})
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_api_keys_domain ON api_keys(domain)")
+
+	// Let the Admins of every domain manage its API keys
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	for _, domain := range adminDomains(casbin) {
+		for _, action := range []string{"List", "Read", "Create", "Delete"} {
+			casbin.AddPolicy("Admin", domain, "APIKey", action)
+		}
+	}
+}
+
+func (APIKeyTables) Down(db *gorm.DB) {
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	casbin.RemoveFilteredPolicy(2, "APIKey")
+
+	var apiKeys []models.APIKey
+	db.Unscoped().Find(&apiKeys)
+	for _, apiKey := range apiKeys {
+		casbin.DeleteUser(apiKey.UUID.String())
+	}
+
+	db.Migrator().DropTable(&models.APIKey{})
+}
+
+// adminDomains lists the domains having an Admin role
+func adminDomains(enforcer *casbin.Enforcer) []string {
+	policies, _ := enforcer.GetFilteredPolicy(0, "Admin")
+	seen := make(map[string]bool)
+	var domains []string
+	for _, policy := range policies {
+		if !seen[policy[1]] {
+			seen[policy[1]] = true
+			domains = append(domains, policy[1])
+		}
+	}
+	return domains
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"goweb/models"
+
+	"gorm.io/gorm"
+)
+
+type EmailVerificationTables struct{}
+
+func (EmailVerificationTables) Id() string {
+	return "EmailVerificationMigration"
+}
+
+func (EmailVerificationTables) Up(db *gorm.DB) {
+	// Adds the verified email requirement to domains
+	db.Migrator().AutoMigrate(&models.Domain{})
+}
+
+func (EmailVerificationTables) Down(db *gorm.DB) {
+	db.Migrator().DropColumn(&models.Domain{}, "RequireVerifiedEmail")
+}
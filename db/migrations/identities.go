@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"goweb/models"
+
+	"gorm.io/gorm"
+)
+
+type IdentityTables struct{}
+
+func (IdentityTables) Id() string {
+	return "IdentityMigration"
+}
+
+// Up creates the identities table and moves the single provider link of existing users into it
+func (IdentityTables) Up(db *gorm.DB) {
+	db.Migrator().AutoMigrate(&models.Identity{})
+
+	var users []models.User
+	db.Where("provider <> ? AND provider <> ? AND provider_id <> ?", "", "local", "").Find(&users)
+	for _, user := range users {
+		db.Create(&models.Identity{
+			UserID:   user.ID,
+			Provider: user.Provider,
+			Subject:  user.ProviderID,
+			Email:    user.Email,
+		})
+	}
+}
+
+func (IdentityTables) Down(db *gorm.DB) {
+	db.Migrator().DropTable(&models.Identity{})
+}
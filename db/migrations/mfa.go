@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"goweb/models"
+
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+type MFATables struct{}
+
+func (MFATables) Id() string {
+	return "MFAMigration"
+}
+
+func (MFATables) Up(db *gorm.DB) {
+	// Adds the TOTP columns to users and the MFA requirement to domains
+	db.Migrator().AutoMigrate(&models.User{}, &models.Domain{}, &models.RecoveryCode{})
+
+	// Let the Admins of every domain manage its settings
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	for _, domain := range adminDomains(casbin) {
+		casbin.AddPolicy("Admin", domain, "DomainSettings", "Read")
+		casbin.AddPolicy("Admin", domain, "DomainSettings", "Update")
+	}
+}
+
+func (MFATables) Down(db *gorm.DB) {
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	casbin.RemoveFilteredPolicy(2, "DomainSettings")
+
+	db.Migrator().DropTable(&models.RecoveryCode{})
+	db.Migrator().DropColumn(&models.Domain{}, "RequireMFA")
+	db.Migrator().DropColumn(&models.User{}, "TOTPSecret")
+	db.Migrator().DropColumn(&models.User{}, "MFAEnabled")
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+type PermissionExplainPolicies struct{}
+
+func (PermissionExplainPolicies) Id() string {
+	return "PermissionExplainMigration"
+}
+
+func (PermissionExplainPolicies) Up(db *gorm.DB) {
+	// Let the Admins of every domain explain the permission decisions made in it
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	for _, domain := range adminDomains(casbin) {
+		casbin.AddPolicy("Admin", domain, "Permission", "Explain")
+	}
+}
+
+func (PermissionExplainPolicies) Down(db *gorm.DB) {
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	casbin.RemoveFilteredPolicy(2, "Permission", "Explain")
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+type PermissionPolicies struct{}
+
+func (PermissionPolicies) Id() string {
+	return "PermissionMigration"
+}
+
+func (PermissionPolicies) Up(db *gorm.DB) {
+	// Let the Admins of every domain manage the permissions of its roles
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	for _, domain := range adminDomains(casbin) {
+		for _, action := range []string{"List", "Create", "Delete"} {
+			casbin.AddPolicy("Admin", domain, "Permission", action)
+		}
+	}
+}
+
+func (PermissionPolicies) Down(db *gorm.DB) {
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	casbin.RemoveFilteredPolicy(2, "Permission")
+}
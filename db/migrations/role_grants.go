@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"goweb/models"
+
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+type RoleGrantTables struct{}
+
+func (RoleGrantTables) Id() string {
+	return "RoleGrantMigration"
+}
+
+func (RoleGrantTables) Up(db *gorm.DB) {
+	db.Migrator().AutoMigrate(&models.RoleGrant{})
+}
+
+// Down removes the assignments of the grants still in place, they would last forever without the table
+func (RoleGrantTables) Down(db *gorm.DB) {
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+
+	var grants []models.RoleGrant
+	db.Where("ended_at IS NULL").Find(&grants)
+	for _, grant := range grants {
+		casbin.DeleteRoleForUserInDomain(grant.Subject, grant.Role, grant.Domain)
+	}
+
+	db.Migrator().DropTable(&models.RoleGrant{})
+}
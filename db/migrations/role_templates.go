@@ -0,0 +1,166 @@
+package migrations
+
+import (
+	"goweb/models"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+type RoleTemplates struct{}
+
+func (RoleTemplates) Id() string {
+	return "RoleTemplateMigration"
+}
+
+// roleTemplatesTable records the template policies Up added to the System domain, so that Down removes
+// only those and leaves the System domain's own policies alone
+const roleTemplatesTable = "role_template_migration"
+
+// Up moves the policies copied into every domain into templates of the System domain. The policy set most
+// domains share for a role becomes its template, unless the System domain defines the role's policies
+// already. Domains with exactly the template's policies drop their copy and inherit it, domains with
+// different policies keep them and override the template. Domains without any policies of a role
+// inherit the template from now on.
+func (RoleTemplates) Up(db *gorm.DB) {
+	casbin := templateEnforcer(db)
+	var system models.Domain
+	if err := db.Where("name = ?", models.SystemDomain).First(&system).Error; err != nil {
+		return
+	}
+	systemUUID := system.UUID.String()
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + roleTemplatesTable + ` (
+		role VARCHAR(100) NOT NULL,
+		object VARCHAR(100) NOT NULL,
+		action VARCHAR(100) NOT NULL
+	)`).Error; err != nil {
+		panic(err)
+	}
+
+	// Policy sets of the roles per domain, as sorted "object action" pairs
+	sets := map[string]map[string][]string{}
+	policies, err := casbin.GetPolicy()
+	if err != nil {
+		panic(err)
+	}
+	for _, policy := range policies {
+		if policy[1] == systemUUID {
+			continue
+		}
+		if sets[policy[0]] == nil {
+			sets[policy[0]] = map[string][]string{}
+		}
+		sets[policy[0]][policy[1]] = append(sets[policy[0]][policy[1]], policy[2]+" "+policy[3])
+	}
+
+	for role, domains := range sets {
+		counts := map[string]int{}
+		for domain, set := range domains {
+			sort.Strings(set)
+			domains[domain] = set
+			counts[strings.Join(set, ",")]++
+		}
+
+		template := policyKeys(casbin, role, systemUUID)
+		if template == "" {
+			for set, count := range counts {
+				if count > counts[template] || count == counts[template] && set < template {
+					template = set
+				}
+			}
+			for _, pair := range strings.Split(template, ",") {
+				objectAction := strings.SplitN(pair, " ", 2)
+				if _, err := casbin.AddPolicy(role, systemUUID, objectAction[0], objectAction[1]); err != nil {
+					panic(err)
+				}
+				if err := db.Exec("INSERT INTO "+roleTemplatesTable+" (role, object, action) VALUES (?, ?, ?)",
+					role, objectAction[0], objectAction[1]).Error; err != nil {
+					panic(err)
+				}
+			}
+		}
+
+		for domain, set := range domains {
+			if strings.Join(set, ",") == template {
+				if _, err := casbin.RemoveFilteredPolicy(0, role, domain); err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
+}
+
+// Down copies the templates into every domain without policies of its own for the role and removes the
+// templates Up added from the System domain
+func (RoleTemplates) Down(db *gorm.DB) {
+	casbin := templateEnforcer(db)
+	var system models.Domain
+	if err := db.Where("name = ?", models.SystemDomain).First(&system).Error; err != nil {
+		return
+	}
+	var domains []models.Domain
+	if err := db.Where("id <> ?", system.ID).Find(&domains).Error; err != nil {
+		panic(err)
+	}
+
+	templates, err := casbin.GetFilteredPolicy(1, system.UUID.String())
+	if err != nil {
+		panic(err)
+	}
+	for _, domain := range domains {
+		inherits := map[string]bool{}
+		for _, template := range templates {
+			if _, seen := inherits[template[0]]; !seen {
+				inherits[template[0]] = policyKeys(casbin, template[0], domain.UUID.String()) == ""
+			}
+			if inherits[template[0]] {
+				if _, err := casbin.AddPolicy(template[0], domain.UUID.String(), template[2], template[3]); err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
+
+	var added []struct{ Role, Object, Action string }
+	if err := db.Raw("SELECT role, object, action FROM " + roleTemplatesTable).Scan(&added).Error; err != nil {
+		panic(err)
+	}
+	for _, policy := range added {
+		if _, err := casbin.RemovePolicy(policy.Role, system.UUID.String(), policy.Object, policy.Action); err != nil {
+			panic(err)
+		}
+	}
+	if err := db.Exec("DROP TABLE " + roleTemplatesTable).Error; err != nil {
+		panic(err)
+	}
+}
+
+// templateEnforcer returns an enforcer on the casbin table, a migration can not go on without one
+func templateEnforcer(db *gorm.DB) *casbin.Enforcer {
+	adaptor, err := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	if err != nil {
+		panic(err)
+	}
+	enforcer, err := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	if err != nil {
+		panic(err)
+	}
+	return enforcer
+}
+
+// policyKeys joins the sorted "object action" pairs of the role's policies in the domain
+func policyKeys(enforcer *casbin.Enforcer, role, domain string) string {
+	policies, err := enforcer.GetFilteredPolicy(0, role, domain)
+	if err != nil {
+		panic(err)
+	}
+	keys := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		keys = append(keys, policy[2]+" "+policy[3])
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+type SessionPolicies struct{}
+
+func (SessionPolicies) Id() string {
+	return "SessionMigration"
+}
+
+func (SessionPolicies) Up(db *gorm.DB) {
+	// Let the Admins of every domain list and revoke the sessions of its users
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	for _, domain := range adminDomains(casbin) {
+		for _, action := range []string{"List", "Read", "Delete"} {
+			casbin.AddPolicy("Admin", domain, "Session", action)
+		}
+	}
+}
+
+func (SessionPolicies) Down(db *gorm.DB) {
+	adaptor, _ := ga.NewAdapterByDBUseTableName(db, "", "casbin")
+	casbin, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	casbin.RemoveFilteredPolicy(2, "Session")
+}
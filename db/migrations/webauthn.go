@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"goweb/models"
+
+	"gorm.io/gorm"
+)
+
+type WebAuthnTables struct{}
+
+func (WebAuthnTables) Id() string {
+	return "WebAuthnMigration"
+}
+
+func (WebAuthnTables) Up(db *gorm.DB) {
+	db.Migrator().AutoMigrate(&models.WebAuthnCredential{})
+}
+
+func (WebAuthnTables) Down(db *gorm.DB) {
+	db.Migrator().DropTable(&models.WebAuthnCredential{})
+}
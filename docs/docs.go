@@ -15,14 +15,35 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/domain": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Returns the JSON Web Key Set other services use to verify access tokens by their ` + "`" + `kid` + "`" + ` header. Empty when tokens are signed with a shared secret (HS512).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Access token signing keys",
+                "operationId": "jwks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/keys.JWKSet"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/apikey": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a sample response for listing domains.",
+                "description": "Returns the API keys of the specified domain. Secrets are never returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,13 +51,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Domain Management"
+                    "API Key Management"
                 ],
-                "summary": "List domains",
-                "operationId": "domain-list",
+                "summary": "List API keys",
+                "operationId": "apikey-list",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.APIKeyResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
@@ -49,7 +79,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new domain with automatic partition creation.",
+                "description": "Creates an API key acting with the given role in the specified domain. The role must be one the caller holds.\nA key with a role granted to the caller for a limited time expires when the grant does.\nThe secret is only part of this response, send it with the key as ` + "`" + `X-API-Key` + "`" + ` and ` + "`" + `X-API-Secret` + "`" + ` headers.",
                 "consumes": [
                     "application/json"
                 ],
@@ -57,18 +87,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Domain Management"
+                    "API Key Management"
                 ],
-                "summary": "Create domain",
-                "operationId": "domain-create",
+                "summary": "Create API key",
+                "operationId": "apikey-create",
                 "parameters": [
                     {
-                        "description": "Domain creation request",
-                        "name": "domain",
+                        "description": "API key creation data",
+                        "name": "params",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.CreateDomainRequest"
+                            "$ref": "#/definitions/requests.CreateAPIKeyRequest"
                         }
                     }
                 ],
@@ -76,7 +106,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/api.Response"
+                            "$ref": "#/definitions/responses.APIKeySecretResponse"
                         }
                     },
                     "400": {
@@ -85,8 +115,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
@@ -94,14 +124,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/domain/{uuid}": {
+        "/api/apikey/{uuid}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Not implemented.",
+                "description": "Returns an API key of the specified domain. The secret is never returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -109,20 +139,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Domain Management"
+                    "API Key Management"
                 ],
-                "summary": "Get domain",
-                "operationId": "domain-read",
+                "summary": "Get API key",
+                "operationId": "apikey-read",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Domain UUID",
+                        "description": "API key UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.APIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -131,13 +173,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Not implemented.",
+                "description": "Revokes an API key of the specified domain, requests using it are rejected immediately.",
                 "consumes": [
                     "application/json"
                 ],
@@ -145,20 +187,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Domain Management"
+                    "API Key Management"
                 ],
-                "summary": "Update domain",
-                "operationId": "domain-update",
+                "summary": "Revoke API key",
+                "operationId": "apikey-delete",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Domain UUID",
+                        "description": "API key UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -166,14 +220,43 @@ const docTemplate = `{
                         }
                     }
                 }
+            }
+        },
+        "/api/domain": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a sample response for listing domains.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domain Management"
+                ],
+                "summary": "List domains",
+                "operationId": "domain-list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Not implemented.",
+                "description": "Creates a new domain with automatic partition creation.",
                 "consumes": [
                     "application/json"
                 ],
@@ -183,20 +266,34 @@ const docTemplate = `{
                 "tags": [
                     "Domain Management"
                 ],
-                "summary": "Delete domain",
-                "operationId": "domain-delete",
+                "summary": "Create domain",
+                "operationId": "domain-create",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Domain UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "Domain creation request",
+                        "name": "domain",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.CreateDomainRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "404": {
-                        "description": "Not Found",
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
@@ -204,14 +301,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/post": {
+        "/api/domain/settings": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a list of posts for the specified domain.",
+                "description": "Returns the settings of the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -219,18 +316,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Post Management"
+                    "Domain Management"
                 ],
-                "summary": "List posts",
-                "operationId": "post-list",
+                "summary": "Get domain settings",
+                "operationId": "domain-settings-read",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/responses.PostResponse"
-                            }
+                            "$ref": "#/definitions/responses.DomainSettingsResponse"
                         }
                     },
                     "400": {
@@ -241,13 +335,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new post in the specified domain.",
+                "description": "Updates the settings of the specified domain. With ` + "`" + `require_mfa` + "`" + ` members can only access the domain with tokens from a two-factor login, with ` + "`" + `require_verified_email` + "`" + ` only after verifying their email.",
                 "consumes": [
                     "application/json"
                 ],
@@ -255,26 +349,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Post Management"
+                    "Domain Management"
                 ],
-                "summary": "Create post",
-                "operationId": "post-create",
+                "summary": "Update domain settings",
+                "operationId": "domain-settings-update",
                 "parameters": [
                     {
-                        "description": "Post creation data",
+                        "description": "Domain settings",
                         "name": "params",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.CreatePostRequest"
+                            "$ref": "#/definitions/requests.DomainSettingsRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.Response"
+                            "$ref": "#/definitions/responses.DomainSettingsResponse"
                         }
                     },
                     "400": {
@@ -282,18 +376,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
                     }
                 }
             }
         },
-        "/api/post/{uuid}": {
+        "/api/domain/{uuid}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns the details of a post by UUID within the specified domain.",
+                "description": "Not implemented.",
                 "consumes": [
                     "application/json"
                 ],
@@ -301,32 +401,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Post Management"
+                    "Domain Management"
                 ],
-                "summary": "Get post",
-                "operationId": "post-read",
+                "summary": "Get domain",
+                "operationId": "domain-read",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post UUID",
+                        "description": "Domain UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.PostResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/api.Response"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -341,7 +429,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Modifies the details of a post by UUID within the specified domain.",
+                "description": "Not implemented.",
                 "consumes": [
                     "application/json"
                 ],
@@ -349,41 +437,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Post Management"
+                    "Domain Management"
                 ],
-                "summary": "Update post",
-                "operationId": "post-update",
+                "summary": "Update domain",
+                "operationId": "domain-update",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post UUID",
+                        "description": "Domain UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Post update data",
-                        "name": "params",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/requests.UpdatePostRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.PostResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/api.Response"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -398,7 +465,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Removes a post by UUID from the specified domain.",
+                "description": "Not implemented.",
                 "consumes": [
                     "application/json"
                 ],
@@ -406,32 +473,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Post Management"
+                    "Domain Management"
                 ],
-                "summary": "Delete post",
-                "operationId": "post-delete",
+                "summary": "Delete domain",
+                "operationId": "domain-delete",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post UUID",
+                        "description": "Domain UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/api.Response"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/api.Response"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -441,14 +496,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/role": {
+        "/api/me/permissions": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a list of roles for the specified domain.",
+                "description": "Returns the actions on object types the caller may perform in the specified domain, through any of its roles including inherited ones. Meant for the frontend to decide which actions to offer.",
                 "consumes": [
                     "application/json"
                 ],
@@ -456,17 +511,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role Management"
+                    "Permission Management"
                 ],
-                "summary": "List roles",
-                "operationId": "role-list",
+                "summary": "List my permissions",
+                "operationId": "permission-mine",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Role"
+                                "$ref": "#/definitions/responses.EffectivePermissionResponse"
                             }
                         }
                     },
@@ -475,16 +530,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
-                    }
-                }
-            },
-            "post": {
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/me/permissions/check": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new role in the specified domain.",
+                "description": "Decides for each action on an object type whether the caller may perform it in the specified domain. With an owner the check is for a resource of that user, which permissions limited to the own resources apply to. The results are in the order of the checks, at most 100 checks per request.",
                 "consumes": [
                     "application/json"
                 ],
@@ -492,26 +555,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role Management"
+                    "Permission Management"
                 ],
-                "summary": "Create role",
-                "operationId": "role-create",
+                "summary": "Check my permissions",
+                "operationId": "permission-check-mine",
                 "parameters": [
                     {
-                        "description": "Role creation data",
+                        "description": "Permissions to check",
                         "name": "params",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.RoleRequest"
+                            "$ref": "#/definitions/requests.PermissionCheckRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.PermissionCheckResponse"
+                            }
                         }
                     },
                     "400": {
@@ -519,18 +585,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
                     }
                 }
             }
         },
-        "/api/role/{uuid}": {
+        "/api/permission": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns the details of a role by UUID within the specified domain.",
+                "description": "Returns the permissions of the roles in the specified domain, optionally of one role only. Roles without permissions of their own in the domain are listed with the templates of the System domain, marked as inherited.",
                 "consumes": [
                     "application/json"
                 ],
@@ -538,24 +610,82 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role Management"
+                    "Permission Management"
                 ],
-                "summary": "Get role",
-                "operationId": "role-read",
+                "summary": "List permissions",
+                "operationId": "permission-list",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Role UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "Only permissions of this role",
+                        "name": "role",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Role"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.PermissionResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Allows a role of the specified domain an action on an object type, e.g. Manager may Create Post. With the condition \"owner\" the action is only allowed on the subject's own resources, e.g. Operator may Update their own Posts. Only permissions the caller holds can be granted. Granting a permission to a role inheriting the System domain's template copies the template into the domain first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission Management"
+                ],
+                "summary": "Grant permission",
+                "operationId": "permission-create",
+                "parameters": [
+                    {
+                        "description": "Permission to grant",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.PermissionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
                         }
                     },
                     "400": {
@@ -564,21 +694,39 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
                     }
                 }
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Modifies the details of a role by UUID within the specified domain.",
+                "description": "Removes an action on an object type from a role of the specified domain. Revoking an inherited permission copies the rest of the System domain's template into the domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -586,25 +734,106 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role Management"
+                    "Permission Management"
                 ],
-                "summary": "Update role",
-                "operationId": "role-update",
+                "summary": "Revoke permission",
+                "operationId": "permission-delete",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Role UUID",
-                        "name": "uuid",
-                        "in": "path",
+                        "description": "Role",
+                        "name": "role",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Role update data",
+                        "type": "string",
+                        "description": "Object type",
+                        "name": "object",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action",
+                        "name": "action",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Condition of a conditional permission",
+                        "name": "condition",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/permission/explain": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Decides whether a subject (user or API key UUID, or user email) may perform an action on an object type and returns the matching rule and the chain of roles leading to it. Subjects without a role or role grant in the domain are not found. The domain defaults to the specified domain, other domains require the Explain permission there as well. Conditional permissions decide on the owner, the UUID of the user the resource belongs to. With what_if the decision is made as if the proposed policies and roles were saved, nothing is changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission Management"
+                ],
+                "summary": "Explain permission decision",
+                "operationId": "permission-explain",
+                "parameters": [
+                    {
+                        "description": "Decision to explain",
                         "name": "params",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.RoleRequest"
+                            "$ref": "#/definitions/requests.ExplainRequest"
                         }
                     }
                 ],
@@ -612,7 +841,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Role"
+                            "$ref": "#/definitions/responses.ExplainResponse"
                         }
                     },
                     "400": {
@@ -621,21 +850,35 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/api/permission/override": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Removes a role by UUID from the specified domain.",
+                "description": "Removes the permissions a role of the specified domain defines itself, so the role inherits the template of the System domain again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -643,16 +886,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role Management"
+                    "Permission Management"
                 ],
-                "summary": "Delete role",
-                "operationId": "role-delete",
+                "summary": "Reset role to template",
+                "operationId": "permission-reset-override",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Role UUID",
-                        "name": "uuid",
-                        "in": "path",
+                        "description": "Role",
+                        "name": "role",
+                        "in": "query",
                         "required": true
                     }
                 ],
@@ -669,23 +912,35 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
                     }
                 }
             }
         },
-        "/api/user": {
+        "/api/post": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a list of users for the specified domain.",
+                "description": "Returns a list of posts for the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -693,17 +948,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Post Management"
                 ],
-                "summary": "List users",
-                "operationId": "user-list",
+                "summary": "List posts",
+                "operationId": "post-list",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/responses.PostResponse"
                             }
                         }
                     },
@@ -721,7 +976,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new user in the specified domain.",
+                "description": "Creates a new post in the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -729,18 +984,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Post Management"
                 ],
-                "summary": "Create user",
-                "operationId": "user-create",
+                "summary": "Create post",
+                "operationId": "post-create",
                 "parameters": [
                     {
-                        "description": "User registration data",
+                        "description": "Post creation data",
                         "name": "params",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.RegisterRequest"
+                            "$ref": "#/definitions/requests.CreatePostRequest"
                         }
                     }
                 ],
@@ -760,14 +1015,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/{uuid}": {
+        "/api/post/{uuid}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns the details of a user by UUID within the specified domain.",
+                "description": "Returns the details of a post by UUID within the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -775,14 +1030,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Post Management"
                 ],
-                "summary": "Get user",
-                "operationId": "user-read",
+                "summary": "Get post",
+                "operationId": "post-read",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User UUID",
+                        "description": "Post UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
@@ -792,7 +1047,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/responses.PostResponse"
                         }
                     },
                     "400": {
@@ -815,7 +1070,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Modifies the details of a user by UUID within the specified domain.",
+                "description": "Modifies the details of a post by UUID within the specified domain. Callers allowed to update only their own posts get 403 for posts of others.",
                 "consumes": [
                     "application/json"
                 ],
@@ -823,25 +1078,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Post Management"
                 ],
-                "summary": "Update user",
-                "operationId": "user-update",
+                "summary": "Update post",
+                "operationId": "post-update",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User UUID",
+                        "description": "Post UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "User update data",
+                        "description": "Post update data",
                         "name": "params",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.UpdateRequest"
+                            "$ref": "#/definitions/requests.UpdatePostRequest"
                         }
                     }
                 ],
@@ -849,7 +1104,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/responses.PostResponse"
                         }
                     },
                     "400": {
@@ -858,6 +1113,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -872,7 +1133,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Removes a user by UUID from the specified domain.",
+                "description": "Removes a post by UUID from the specified domain. Callers allowed to delete only their own posts get 403 for posts of others.",
                 "consumes": [
                     "application/json"
                 ],
@@ -880,14 +1141,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Post Management"
                 ],
-                "summary": "Delete user",
-                "operationId": "user-delete",
+                "summary": "Delete post",
+                "operationId": "post-delete",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User UUID",
+                        "description": "Post UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
@@ -906,6 +1167,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -915,9 +1182,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/github": {
+        "/api/role": {
             "get": {
-                "description": "Redirects user to GitHub OAuth for authentication",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a list of roles for the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -925,15 +1197,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Social Login"
+                    "Role Management"
                 ],
-                "summary": "Initiate GitHub OAuth login",
-                "operationId": "github-login",
+                "summary": "List roles",
+                "operationId": "role-list",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Role"
+                            }
                         }
                     },
                     "400": {
@@ -941,19 +1216,16 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/api.Response"
-                        }
                     }
                 }
-            }
-        },
-        "/auth/github/callback": {
-            "get": {
-                "description": "Processes the OAuth callback from GitHub and authenticates the user",
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new role in the specified domain, optionally inheriting the permissions of a parent role. Only parents whose permissions the caller holds can be inherited.",
                 "consumes": [
                     "application/json"
                 ],
@@ -961,30 +1233,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Social Login"
+                    "Role Management"
                 ],
-                "summary": "Handle GitHub OAuth callback",
-                "operationId": "github-callback",
+                "summary": "Create role",
+                "operationId": "role-create",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Authorization code from GitHub",
-                        "name": "code",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "State parameter",
-                        "name": "state",
-                        "in": "query"
+                        "description": "Role creation data",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.RoleRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/responses.LoginResponse"
+                            "$ref": "#/definitions/api.Response"
                         }
                     },
                     "400": {
@@ -993,14 +1261,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
@@ -1008,9 +1282,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/google": {
+        "/api/role/{uuid}": {
             "get": {
-                "description": "Redirects user to Google OAuth for authentication",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the details of a role by UUID within the specified domain: its parent role, the permissions granted to it directly and the permissions it has in effect through its parent roles.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1018,15 +1297,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Social Login"
+                    "Role Management"
+                ],
+                "summary": "Get role",
+                "operationId": "role-read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Role UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Initiate Google OAuth login",
-                "operationId": "google-login",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.Response"
+                            "$ref": "#/definitions/responses.RoleResponse"
                         }
                     },
                     "400": {
@@ -1035,18 +1323,21 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
                     }
                 }
-            }
-        },
-        "/auth/google/callback": {
-            "get": {
-                "description": "Processes the OAuth callback from Google and authenticates the user",
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Modifies the details of a role by UUID within the specified domain. A rename carries the role's policies, user assignments and links to other roles over to the new name. The parent replaces the role the role inherits from, an empty parent removes it. Parents of System roles apply in every domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1054,30 +1345,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Social Login"
+                    "Role Management"
                 ],
-                "summary": "Handle Google OAuth callback",
-                "operationId": "google-callback",
+                "summary": "Update role",
+                "operationId": "role-update",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Authorization code from Google",
-                        "name": "code",
-                        "in": "query",
+                        "description": "Role UUID",
+                        "name": "uuid",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "State parameter",
-                        "name": "state",
-                        "in": "query"
+                        "description": "Role update data",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.RoleRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.LoginResponse"
+                            "$ref": "#/definitions/responses.RoleResponse"
                         }
                     },
                     "400": {
@@ -1086,24 +1380,33 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
                     }
                 }
-            }
-        },
-        "/login": {
-            "post": {
-                "description": "Perform user login with email and password. The returned ` + "`" + `accessToken` + "`" + ` should be used as a Bearer token in the ` + "`" + `Authorization` + "`" + ` header (i.e., ` + "`" + `Authorization: Bearer \u003caccessToken\u003e` + "`" + `) for authenticated endpoints such as Logout.",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a role by UUID from the specified domain together with its policies and user assignments. Roles inheriting from it no longer do.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1111,26 +1414,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Account Actions"
+                    "Role Management"
                 ],
-                "summary": "Authenticates a user using email and password, and returns a token pair if successful.",
-                "operationId": "user-login",
+                "summary": "Delete role",
+                "operationId": "role-delete",
                 "parameters": [
                     {
-                        "description": "User's credentials",
-                        "name": "params",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/requests.LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "Role UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.LoginResponse"
+                            "$ref": "#/definitions/api.Response"
                         }
                     },
                     "400": {
@@ -1139,14 +1440,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/api.Response"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
@@ -1154,14 +1449,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/logout": {
-            "post": {
+        "/api/session": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Invalidate user session and logout",
+                "description": "Returns the active sessions of a user of the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1169,35 +1464,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Account Actions"
+                    "Session Management"
+                ],
+                "summary": "List sessions of a user",
+                "operationId": "session-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Logout user",
-                "operationId": "user-logout",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.SessionResponse"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
                     }
                 }
-            }
-        },
-        "/refresh": {
-            "post": {
-                "description": "RefreshToken issues a new access token using a valid refresh token.",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes every session of a user of the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1205,26 +1515,74 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Account Actions"
+                    "Session Management"
                 ],
-                "summary": "Refresh access token",
-                "operationId": "user-refresh",
+                "summary": "Revoke all sessions of a user",
+                "operationId": "session-delete-all",
                 "parameters": [
                     {
-                        "description": "Refresh token",
-                        "name": "params",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/requests.RefreshRequest"
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
                         }
                     }
+                }
+            }
+        },
+        "/api/session/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a session of a user of the specified domain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session Management"
+                ],
+                "summary": "Get session",
+                "operationId": "session-read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.LoginResponse"
+                            "$ref": "#/definitions/responses.SessionResponse"
                         }
                     },
                     "400": {
@@ -1233,14 +1591,56 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes a session of a user of the specified domain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session Management"
+                ],
+                "summary": "Revoke session",
+                "operationId": "session-delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.Response"
                         }
@@ -1248,9 +1648,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
+        "/api/user": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a list of users for the specified domain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "List users",
+                "operationId": "user-list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Register creates a new user and domain based on the provided registration request.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new user in the specified domain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1258,13 +1699,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Account Actions"
+                    "User Management"
                 ],
-                "summary": "Register a new user",
-                "operationId": "user-register",
+                "summary": "Create user",
+                "operationId": "user-create",
                 "parameters": [
                     {
-                        "description": "User's email, user's password",
+                        "description": "User registration data",
                         "name": "params",
                         "in": "body",
                         "required": true,
@@ -1273,293 +1714,3220 @@ const docTemplate = `{
                         }
                     }
                 ],
-                "responses": {}
-            }
-        }
-    },
-    "definitions": {
-        "api.Response": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "error": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
                 }
             }
         },
-        "models.Domain": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
-                },
-                "users": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.User"
+        "/api/user/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the details of a user by UUID within the specified domain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Get user",
+                "operationId": "user-read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
                     }
-                },
-                "uuid": {
-                    "type": "string"
                 }
-            }
-        },
-        "models.Post": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "domain": {
-                    "type": "string"
-                },
-                "title": {
-                    "type": "string"
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Modifies the details of a user by UUID within the specified domain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Update user",
+                "operationId": "user-update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User update data",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.UpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a user by UUID from the specified domain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Delete user",
+                "operationId": "user-delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/{uuid}/lockout": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lifts the login lockout and backoff of a user of the specified domain before they expire.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Unlock user login",
+                "operationId": "user-unlock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/{uuid}/role": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the roles a user of the specified domain is assigned in it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "List user roles",
+                "operationId": "user-role-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Assigns a role of the specified domain to a user of it. Only roles whose permissions the caller holds can be assigned.\nWith valid_until the role is granted for a limited time, from valid_from or right away. It is in effect only within that window and removed once it has expired.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Assign role to user",
+                "operationId": "user-role-assign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role to assign",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.RoleAssignmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/{uuid}/role/grants": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the time-bound role grants of a user of the specified domain, newest first. Ended grants are included with the time and reason they ended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "List time-bound role grants of user",
+                "operationId": "user-role-grant-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RoleGrant"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/{uuid}/role/{role}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a role of the specified domain from a user of it. Only roles whose permissions the caller holds can be removed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Unassign role from user",
+                "operationId": "user-role-unassign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/github": {
+            "get": {
+                "description": "Redirects user to GitHub OAuth for authentication. Every login gets its own state and PKCE verifier, valid for 10 minutes and bound to the browser by a cookie.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social Login"
+                ],
+                "summary": "Initiate GitHub OAuth login",
+                "operationId": "github-login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Relative frontend path handed back to /auth/callback after the login",
+                        "name": "redirect_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "307": {
+                        "description": "Redirect to the provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/github/callback": {
+            "get": {
+                "description": "Processes the OAuth callback from GitHub and authenticates the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social Login"
+                ],
+                "summary": "Handle GitHub OAuth callback",
+                "operationId": "github-callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code from GitHub",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State parameter issued by /auth/github",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "307": {
+                        "description": "Redirect to /auth/callback of the frontend with the tokens",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/google": {
+            "get": {
+                "description": "Redirects user to Google OAuth for authentication. Every login gets its own state and PKCE verifier, valid for 10 minutes and bound to the browser by a cookie.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social Login"
+                ],
+                "summary": "Initiate Google OAuth login",
+                "operationId": "google-login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Relative frontend path handed back to /auth/callback after the login",
+                        "name": "redirect_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "307": {
+                        "description": "Redirect to the provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/google/callback": {
+            "get": {
+                "description": "Processes the OAuth callback from Google and authenticates the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social Login"
+                ],
+                "summary": "Handle Google OAuth callback",
+                "operationId": "google-callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code from Google",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State parameter issued by /auth/google",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "307": {
+                        "description": "Redirect to /auth/callback of the frontend with the tokens",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/{provider}": {
+            "get": {
+                "description": "Redirects user to a configured OpenID Connect provider (e.g. Keycloak, Azure AD) for authentication. Like Google and GitHub logins, every login gets its own state, PKCE verifier and nonce, valid for 10 minutes and bound to the browser by a cookie.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social Login"
+                ],
+                "summary": "Initiate OpenID Connect login",
+                "operationId": "oidc-login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the provider in OIDC_PROVIDERS",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Relative frontend path handed back to /auth/callback after the login",
+                        "name": "redirect_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "307": {
+                        "description": "Redirect to the provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/{provider}/callback": {
+            "get": {
+                "description": "Processes the callback of an OpenID Connect provider, verifies the ID token against the provider's JWKS and authenticates the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social Login"
+                ],
+                "summary": "Handle OpenID Connect callback",
+                "operationId": "oidc-callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the provider in OIDC_PROVIDERS",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code from the provider",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State parameter issued by /auth/{provider}",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "307": {
+                        "description": "Redirect to /auth/callback of the frontend with the tokens",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/identities": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the social and OpenID Connect accounts linked to the current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "List my linked accounts",
+                "operationId": "identity-list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.IdentityResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/identities/{provider}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Starts a login with the provider (google, github or a name in OIDC_PROVIDERS) that links the provider account to the current user instead of logging in. Send the browser to the returned URL; after the provider the browser returns to ` + "`" + `redirect_to` + "`" + ` (default ` + "`" + `/` + "`" + `) with ` + "`" + `linked=\u003cprovider\u003e` + "`" + ` or ` + "`" + `link_error=\u003cmessage\u003e` + "`" + `.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Link a social account",
+                "operationId": "identity-link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider to link",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Relative frontend path to return to after the link",
+                        "name": "redirect_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.IdentityLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/identities/{uuid}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unlinks a social or OpenID Connect account from the current user, it can no longer be used to log in. The last way to log in cannot be unlinked: set a password, add a passkey or link another account first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Unlink account",
+                "operationId": "identity-unlink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Linked account UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Perform user login with email and password. The returned ` + "`" + `accessToken` + "`" + ` should be used as a Bearer token in the ` + "`" + `Authorization` + "`" + ` header (i.e., ` + "`" + `Authorization: Bearer \u003caccessToken\u003e` + "`" + `) for authenticated endpoints such as Logout. Every login starts its own session, the optional ` + "`" + `X-Device-Name` + "`" + ` header names the device of that session.\nUsers with two-factor authentication get a 202 with an ` + "`" + `mfa_token` + "`" + ` instead, exchange it with a code at ` + "`" + `/login/mfa` + "`" + `.\nEach failed attempt doubles the wait before the next one, after too many failures of an account or IP the login is locked for a while. Both are answered with a 429 and a ` + "`" + `Retry-After` + "`" + ` header, lockouts with the code ` + "`" + `100017` + "`" + `.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Authenticates a user using email and password, and returns a token pair if successful.",
+                "operationId": "user-login",
+                "parameters": [
+                    {
+                        "description": "User's credentials",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.LoginRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the device the session is created for",
+                        "name": "X-Device-Name",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.LoginResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MFAChallengeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/mfa": {
+            "post": {
+                "description": "Exchanges the ` + "`" + `mfa_token` + "`" + ` returned by login and a TOTP or recovery code for a token pair. The ` + "`" + `mfa_token` + "`" + ` is invalidated after 5 wrong codes.\nWrong codes count towards the backoff and lockout of the account and IP like wrong passwords, both are answered with a 429 and a ` + "`" + `Retry-After` + "`" + ` header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Completes a two-factor login",
+                "operationId": "user-login-mfa",
+                "parameters": [
+                    {
+                        "description": "MFA token and code",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.MFALoginRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the device the session is created for",
+                        "name": "X-Device-Name",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/passkey/begin": {
+            "post": {
+                "description": "Returns the options to pass to ` + "`" + `navigator.credentials.get` + "`" + ` and a session to send with its result to ` + "`" + `/login/passkey/finish` + "`" + ` within 5 minutes. No email is needed, the user is identified by the passkey.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Starts a passkey login",
+                "operationId": "user-login-passkey-begin",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PasskeyCeremonyResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/passkey/finish": {
+            "post": {
+                "description": "Verifies the assertion of the authenticator and returns a token pair. Passkeys verifying the user with a PIN or biometrics count as two-factor authentication,\nusers with two-factor authentication get a 202 with an ` + "`" + `mfa_token` + "`" + ` for ` + "`" + `/login/mfa` + "`" + ` otherwise.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Completes a passkey login",
+                "operationId": "user-login-passkey-finish",
+                "parameters": [
+                    {
+                        "description": "Passkey session and assertion",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.PasskeyLoginRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the device the session is created for",
+                        "name": "X-Device-Name",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.LoginResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MFAChallengeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Invalidate the current session and logout, sessions on other devices stay active",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Logout user",
+                "operationId": "user-logout",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces all recovery codes after verifying a TOTP or recovery code, previous codes stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Regenerate recovery codes",
+                "operationId": "mfa-recovery-codes",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/mfa/totp": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generates a TOTP secret and its ` + "`" + `otpauth://` + "`" + ` provisioning URI (render it as QR code for authenticator apps). Two-factor authentication is enabled once a code is confirmed within 10 minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Start TOTP enrollment",
+                "operationId": "mfa-totp-enroll",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.TOTPEnrollmentResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disables two-factor authentication after verifying a TOTP or recovery code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Disable TOTP",
+                "operationId": "mfa-totp-disable",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/mfa/totp/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Enables two-factor authentication with the first code of the authenticator and returns 10 single-use recovery codes. Sign in again to get tokens satisfying domains that require two-factor authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Confirm TOTP enrollment",
+                "operationId": "mfa-totp-confirm",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the passkeys of the current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "List my passkeys",
+                "operationId": "passkey-list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.PasskeyResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys/register/begin": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the options to pass to ` + "`" + `navigator.credentials.create` + "`" + ` and a session to send with its result to ` + "`" + `/passkeys/register/finish` + "`" + ` within 5 minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Start passkey registration",
+                "operationId": "passkey-register-begin",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PasskeyCeremonyResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys/register/finish": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Verifies the credential created by the authenticator and stores it as passkey of the current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Finish passkey registration",
+                "operationId": "passkey-register-finish",
+                "parameters": [
+                    {
+                        "description": "Passkey session and created credential",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.PasskeyRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PasskeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys/{uuid}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a passkey of the current user, it can no longer be used to log in. The last passkey of an account without a password or linked account can not be deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Delete passkey",
+                "operationId": "passkey-delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passkey UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "Mails a link with a single-use reset token valid for 30 minutes. The response is the same whether or not the email belongs to an account, requesting again invalidates the previous token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Request a password reset",
+                "operationId": "password-forgot",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "Sets a new password with a reset token. The token is invalidated and all sessions of the user are revoked, sign in again with the new password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Reset password",
+                "operationId": "password-reset",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "RefreshToken issues a new token pair using a valid refresh token. Each refresh token can be used only once, reusing a rotated refresh token revokes all tokens of its login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Refresh access token",
+                "operationId": "user-refresh",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Register creates a new user in the System domain, like social sign-ups, and mails a link to verify the email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Register a new user",
+                "operationId": "user-register",
+                "parameters": [
+                    {
+                        "description": "User's email, user's password",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the active sessions of the current user with device, IP and last activity.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "List my sessions",
+                "operationId": "session-list-mine",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.SessionResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes every session of the current user, including the current one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Revoke all my sessions",
+                "operationId": "session-revoke-all-mine",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{uuid}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes a session of the current user, all tokens of that session stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Revoke one of my sessions",
+                "operationId": "session-revoke-mine",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify-email": {
+            "post": {
+                "description": "Marks the email of the user as verified with the token of the link mailed on registration. Links are valid for 24 hours and only for the address they were sent to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Verify email",
+                "operationId": "email-verify",
+                "parameters": [
+                    {
+                        "description": "Verification token",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify-email/resend": {
+            "post": {
+                "description": "Mails a new verification link. Limited to one email per address every 60 seconds and 10 per IP and hour. The response is the same for unknown and already verified addresses.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account Actions"
+                ],
+                "summary": "Resend verification email",
+                "operationId": "email-verify-resend",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ResendVerificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "keys.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "keys.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/keys.JWK"
+                    }
+                }
+            }
+        },
+        "models.Domain": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "require_mfa": {
+                    "description": "Members must sign in with two-factor authentication",
+                    "type": "boolean"
+                },
+                "require_verified_email": {
+                    "description": "Members must verify their email first",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Post": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "userID": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Role": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "domainID": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RoleGrant": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "end_reason": {
+                    "type": "string"
+                },
+                "ended_at": {
+                    "type": "string"
+                },
+                "granted_by": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "subject": {
+                    "description": "UUID of the user",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Domain"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "is_verified": {
+                    "description": "Email verification status",
+                    "type": "boolean"
+                },
+                "mfa_enabled": {
+                    "description": "TOTP two-factor authentication enrolled",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "posts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Post"
+                    }
+                },
+                "provider": {
+                    "description": "Provider the account was created with, linked accounts are in identities",
+                    "type": "string"
+                },
+                "provider_id": {
+                    "description": "ID from the OAuth provider the account was created with",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "requests.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "role"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer",
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI pipeline"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "Operator"
+                }
+            }
+        },
+        "requests.CreateDomainRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "requests.CreatePostRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "title"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Echo is nice!"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Echo"
+                }
+            }
+        },
+        "requests.DomainSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "require_mfa": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "require_verified_email": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "requests.ExplainRequest": {
+            "type": "object",
+            "required": [
+                "action",
+                "object",
+                "subject"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "Create"
+                },
+                "domain": {
+                    "type": "string",
+                    "example": "5f0c7a52-8f5e-4bb3-9d0e-0d8a2c4a1f11"
+                },
+                "object": {
+                    "type": "string",
+                    "example": "Post"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"
+                },
+                "subject": {
+                    "type": "string",
+                    "example": "userb@localhost"
+                },
+                "what_if": {
+                    "$ref": "#/definitions/requests.WhatIfRequest"
+                }
+            }
+        },
+        "requests.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "username@gmail.com"
+                }
+            }
+        },
+        "requests.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "username@gmail.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "P@ssw0r6"
+                }
+            }
+        },
+        "requests.MFACodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "requests.MFALoginRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
                 },
-                "updated_at": {
-                    "type": "string"
+                "mfa_token": {
+                    "type": "string",
+                    "example": "mfa_token"
+                }
+            }
+        },
+        "requests.PasskeyLoginRequest": {
+            "type": "object",
+            "required": [
+                "credential",
+                "session"
+            ],
+            "properties": {
+                "credential": {
+                    "type": "object"
+                },
+                "session": {
+                    "type": "string",
+                    "example": "passkey_session"
+                }
+            }
+        },
+        "requests.PasskeyRegistrationRequest": {
+            "type": "object",
+            "required": [
+                "credential",
+                "session"
+            ],
+            "properties": {
+                "credential": {
+                    "type": "object"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "MacBook Touch ID"
+                },
+                "session": {
+                    "type": "string",
+                    "example": "passkey_session"
+                }
+            }
+        },
+        "requests.PermissionCheck": {
+            "type": "object",
+            "required": [
+                "action",
+                "resource"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "Create"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"
+                },
+                "resource": {
+                    "type": "string",
+                    "example": "Post"
+                }
+            }
+        },
+        "requests.PermissionCheckRequest": {
+            "type": "object",
+            "required": [
+                "checks"
+            ],
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/requests.PermissionCheck"
+                    }
+                }
+            }
+        },
+        "requests.PermissionRequest": {
+            "type": "object",
+            "required": [
+                "action",
+                "object",
+                "role"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "Create"
+                },
+                "condition": {
+                    "type": "string",
+                    "example": "owner"
+                },
+                "object": {
+                    "type": "string",
+                    "example": "Post"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "Manager"
+                }
+            }
+        },
+        "requests.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "refresh_token"
+                }
+            }
+        },
+        "requests.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "username@gmail.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "P@ssw0r6"
+                }
+            }
+        },
+        "requests.ResendVerificationRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "username@gmail.com"
+                }
+            }
+        },
+        "requests.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "P@ssw0r6"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "reset_token"
+                }
+            }
+        },
+        "requests.RoleAssignmentRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "Manager"
+                },
+                "valid_from": {
+                    "type": "string",
+                    "example": "2026-01-01T08:00:00Z"
+                },
+                "valid_until": {
+                    "type": "string",
+                    "example": "2026-01-01T18:00:00Z"
+                }
+            }
+        },
+        "requests.RoleRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Manager"
+                },
+                "parent": {
+                    "type": "string",
+                    "example": "Operator"
+                }
+            }
+        },
+        "requests.UpdatePostRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "title"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Echo is nice!"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Echo"
+                }
+            }
+        },
+        "requests.UpdateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "John Doe"
+                }
+            }
+        },
+        "requests.VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "verification_token"
+                }
+            }
+        },
+        "requests.WhatIfRequest": {
+            "type": "object",
+            "properties": {
+                "add_policies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/requests.PermissionRequest"
+                    }
+                },
+                "add_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Manager"
+                    ]
+                },
+                "remove_policies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/requests.PermissionRequest"
+                    }
+                },
+                "remove_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Operator"
+                    ]
+                }
+            }
+        },
+        "responses.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2023-04-01T00:00:00Z"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "3f2a9c0e5b7d4e61a8c9d0e1f2a3b4c5"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI pipeline"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "Operator"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "uuid"
+                }
+            }
+        },
+        "responses.APIKeySecretResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2023-04-01T00:00:00Z"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "3f2a9c0e5b7d4e61a8c9d0e1f2a3b4c5"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI pipeline"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "John Doe"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "role": {
+                    "type": "string",
+                    "example": "Operator"
                 },
-                "userID": {
-                    "type": "integer"
+                "secret": {
+                    "type": "string",
+                    "example": "9b1c...e4"
                 },
                 "uuid": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "uuid"
                 }
             }
         },
-        "models.Role": {
+        "responses.DomainSettingsResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "domainID": {
-                    "type": "integer"
-                },
                 "name": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "Reliance"
                 },
-                "updated_at": {
-                    "type": "string"
+                "require_mfa": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "require_verified_email": {
+                    "type": "boolean",
+                    "example": true
                 },
                 "uuid": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "uuid"
                 }
             }
         },
-        "models.User": {
+        "responses.EffectivePermissionResponse": {
             "type": "object",
             "properties": {
-                "avatar": {
-                    "type": "string"
+                "action": {
+                    "type": "string",
+                    "example": "Create"
                 },
-                "created_at": {
-                    "type": "string"
+                "condition": {
+                    "type": "string",
+                    "example": "owner"
                 },
-                "domains": {
+                "resource": {
+                    "type": "string",
+                    "example": "Post"
+                }
+            }
+        },
+        "responses.ExplainResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "Create"
+                },
+                "allowed": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "chain": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Domain"
-                    }
+                        "type": "string"
+                    },
+                    "example": [
+                        "0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a",
+                        "Manager"
+                    ]
                 },
-                "email": {
-                    "type": "string"
+                "domain": {
+                    "type": "string",
+                    "example": "5f0c7a52-8f5e-4bb3-9d0e-0d8a2c4a1f11"
                 },
-                "is_verified": {
-                    "description": "Email verification status",
-                    "type": "boolean"
+                "object": {
+                    "type": "string",
+                    "example": "Post"
                 },
-                "name": {
-                    "type": "string"
+                "owner": {
+                    "type": "string",
+                    "example": "0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"
                 },
-                "posts": {
+                "roles": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Post"
-                    }
-                },
-                "provider": {
-                    "description": "local, google, github, etc.",
-                    "type": "string"
+                        "type": "string"
+                    },
+                    "example": [
+                        "Manager"
+                    ]
                 },
-                "provider_id": {
-                    "description": "ID from the OAuth provider",
-                    "type": "string"
+                "rule": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Manager",
+                        "5f0c7a52-8f5e-4bb3-9d0e-0d8a2c4a1f11",
+                        "Post",
+                        "Create"
+                    ]
                 },
-                "updated_at": {
-                    "type": "string"
+                "subject": {
+                    "type": "string",
+                    "example": "0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"
                 },
-                "uuid": {
-                    "type": "string"
+                "what_if": {
+                    "type": "boolean",
+                    "example": false
                 }
             }
         },
-        "requests.CreateDomainRequest": {
+        "responses.IdentityLinkResponse": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
+                "url": {
+                    "type": "string",
+                    "example": "https://accounts.google.com/o/oauth2/auth?client_id=..."
                 }
             }
         },
-        "requests.CreatePostRequest": {
+        "responses.IdentityResponse": {
             "type": "object",
-            "required": [
-                "content",
-                "title"
-            ],
             "properties": {
-                "content": {
+                "created_at": {
                     "type": "string",
-                    "example": "Echo is nice!"
+                    "example": "2023-01-01T00:00:00Z"
                 },
-                "title": {
+                "email": {
                     "type": "string",
-                    "example": "Echo"
+                    "example": "john.doe@example.com"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "google"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "uuid"
                 }
             }
         },
-        "requests.LoginRequest": {
+        "responses.LoginResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "username@gmail.com"
+                "accessToken": {
+                    "type": "string"
                 },
-                "password": {
+                "exp": {
+                    "type": "integer"
+                },
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.MFAChallengeResponse": {
+            "type": "object",
+            "properties": {
+                "exp": {
+                    "type": "integer"
+                },
+                "mfa_required": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "mfa_token": {
                     "type": "string",
-                    "example": "P@ssw0r6"
+                    "example": "mfa_token"
                 }
             }
         },
-        "requests.RefreshRequest": {
+        "responses.PasskeyCeremonyResponse": {
             "type": "object",
-            "required": [
-                "token"
-            ],
             "properties": {
-                "token": {
+                "exp": {
+                    "type": "integer"
+                },
+                "options": {
+                    "type": "object"
+                },
+                "session": {
                     "type": "string",
-                    "example": "refresh_token"
+                    "example": "passkey_session"
                 }
             }
         },
-        "requests.RegisterRequest": {
+        "responses.PasskeyResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "name",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "created_at": {
                     "type": "string",
-                    "example": "username@gmail.com"
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
                 },
                 "name": {
                     "type": "string",
-                    "example": "John Doe"
+                    "example": "MacBook Touch ID"
                 },
-                "password": {
+                "synced": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "uuid": {
                     "type": "string",
-                    "example": "P@ssw0r6"
+                    "example": "uuid"
                 }
             }
         },
-        "requests.RoleRequest": {
+        "responses.PermissionCheckResponse": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
+                "action": {
                     "type": "string",
-                    "example": "Operator"
+                    "example": "Create"
+                },
+                "allowed": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "resource": {
+                    "type": "string",
+                    "example": "Post"
                 }
             }
         },
-        "requests.UpdatePostRequest": {
+        "responses.PermissionResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "Create"
+                },
+                "condition": {
+                    "description": "only on resources meeting the condition",
+                    "type": "string",
+                    "example": "owner"
+                },
+                "inherited": {
+                    "description": "template of the System domain",
+                    "type": "boolean",
+                    "example": false
+                },
+                "object": {
+                    "type": "string",
+                    "example": "Post"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "Manager"
+                }
+            }
+        },
+        "responses.PostResponse": {
             "type": "object",
-            "required": [
-                "content",
-                "title"
-            ],
             "properties": {
                 "content": {
                     "type": "string",
                     "example": "Echo is nice!"
                 },
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "owner": {
+                    "description": "UUID of the user who wrote the post",
+                    "type": "string",
+                    "example": "uuid"
+                },
                 "title": {
                     "type": "string",
                     "example": "Echo"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "uuid"
                 }
             }
         },
-        "requests.UpdateRequest": {
+        "responses.RecoveryCodesResponse": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
-                    "type": "string",
-                    "example": "John Doe"
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "4f1c2-9ab07"
+                    ]
                 }
             }
         },
-        "responses.LoginResponse": {
+        "responses.RoleResponse": {
             "type": "object",
             "properties": {
-                "accessToken": {
+                "created_at": {
                     "type": "string"
                 },
-                "exp": {
-                    "type": "integer"
+                "domainID": {
+                    "type": "integer",
+                    "format": "int64"
                 },
-                "refreshToken": {
+                "effective_permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PermissionResponse"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string",
+                    "example": "Operator"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PermissionResponse"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
                     "type": "string"
                 }
             }
         },
-        "responses.PostResponse": {
+        "responses.SessionResponse": {
             "type": "object",
             "properties": {
-                "content": {
+                "created_at": {
                     "type": "string",
-                    "example": "Echo is nice!"
+                    "example": "2023-01-01T00:00:00Z"
                 },
-                "title": {
-                    "type": "string",
-                    "example": "Echo"
+                "current": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "username": {
+                "device": {
                     "type": "string",
-                    "example": "John Doe"
+                    "example": "iPhone"
                 },
-                "uuid": {
+                "id": {
                     "type": "string",
                     "example": "uuid"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "last_seen_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0"
+                }
+            }
+        },
+        "responses.TOTPEnrollmentResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                },
+                "uri": {
+                    "type": "string",
+                    "example": "otpauth://totp/Ohmex:username@gmail.com?secret=JBSWY3DPEHPK3PXP\u0026issuer=Ohmex"
                 }
             }
         }
@@ -6,6 +6,7 @@ export interface Post {
 	title: string;
 	content: string;
 	userID: number;
+	owner?: string;
 	user: {
 		id: number;
 		name: string;
@@ -25,6 +26,19 @@ export interface UpdatePostRequest {
 	content: string;
 }
 
+export interface Permission {
+	resource: string;
+	action: string;
+	// "owner" when the action is only allowed on the user's own resources
+	condition?: string;
+	// UUID of the owner of the resource a check is for
+	owner?: string;
+}
+
+export interface PermissionCheck extends Permission {
+	allowed: boolean;
+}
+
 export interface User {
 	id: number;
 	email: string;
@@ -155,6 +169,18 @@ class ApiService {
 		});
 	}
 
+	// Permissions API, decides which actions the UI offers in the current domain
+	async getMyPermissions(): Promise<Permission[]> {
+		return this.makeRequest<Permission[]>('/me/permissions');
+	}
+
+	async checkPermissions(checks: Permission[]): Promise<PermissionCheck[]> {
+		return this.makeRequest<PermissionCheck[]>('/me/permissions/check', {
+			method: 'POST',
+			body: JSON.stringify({ checks })
+		});
+	}
+
 	// User API
 	async getCurrentUser(): Promise<User> {
 		// This endpoint doesn't exist in the backend yet
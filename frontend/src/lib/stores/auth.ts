@@ -37,6 +37,16 @@ export interface LoginResponse {
 	exp: number;
 }
 
+// Result of the second login step, status and code are the HTTP status and api code of a rejected code
+export interface MFALoginResult {
+	tokens?: LoginResponse;
+	status?: number;
+	code?: number;
+}
+
+// Api code of an expired pending login, or one dropped after too many wrong codes
+export const INVALID_TOKEN_CODE = 100003;
+
 // Create the auth store
 export const authStore = writable<AuthState>({
 	isAuthenticated: false,
@@ -229,6 +239,30 @@ export const authActions = {
 		}
 	},
 
+	// Exchanges the mfa_token of a login waiting for its second factor and a TOTP or recovery code for a token pair
+	completeMFALogin: async (mfaToken: string, code: string): Promise<MFALoginResult> => {
+		try {
+			const response = await fetch('/login/mfa', {
+				method: 'POST',
+				headers: {
+					'Content-Type': 'application/json'
+				},
+				body: JSON.stringify({ mfa_token: mfaToken, code })
+			});
+
+			console.log('MFA login response status:', response.status);
+			const data = await response.json();
+			if (!response.ok) {
+				console.error('MFA login failed with error:', data);
+				return { status: response.status, code: data.code };
+			}
+			return { tokens: data as LoginResponse };
+		} catch (error) {
+			console.error('MFA login error:', error);
+			return { status: 0 };
+		}
+	},
+
 	getAuthHeaders: (): Record<string, string> => {
 		const token = localStorage.getItem('accessToken');
 		return token ? { 'Authorization': `Bearer ${token}` } : {};
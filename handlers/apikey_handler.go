@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/requests"
+	"goweb/responses"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// APIKeyHandler provides endpoints for managing the API keys machine clients use within a domain.
+type APIKeyHandler struct {
+	BaseHandler
+	apiKeyService *services.APIKeyService
+	auditService  *services.AuditService
+}
+
+// NewAPIKeyHandler initializes the APIKeyHandler with the provided server and its dependencies.
+func NewAPIKeyHandler(server *server.Server) *APIKeyHandler {
+	return &APIKeyHandler{
+		BaseHandler: BaseHandler{
+			Server: server,
+		},
+		apiKeyService: services.NewAPIKeyService(server),
+		auditService:  services.NewAuditService(),
+	}
+}
+
+// Type returns the string identifier for the APIKeyHandler.
+func (h *APIKeyHandler) Type() string {
+	return "APIKey"
+}
+
+// findKeyInDomain loads the API key referenced by the 'uuid' path parameter within the domain
+func (h *APIKeyHandler) findKeyInDomain(e echo.Context) (*models.APIKey, error) {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return nil, api.FIELD_VALIDATION_ERROR("Missing domain information")
+	}
+	domain, _ := d.(*models.Domain)
+
+	uuid, err := util.GetUUIDParam(e)
+	if err != nil {
+		return nil, api.FIELD_VALIDATION_ERROR("Missing or invalid UUID parameter")
+	}
+
+	apiKey := new(models.APIKey)
+	if err := h.apiKeyService.GetKeyByUuidInDomain(apiKey, uuid, domain); err != nil || apiKey.ID == 0 {
+		return nil, api.RESOURCE_NOT_FOUND("API key not found")
+	}
+	return apiKey, nil
+}
+
+// List godoc
+// @Summary List API keys
+// @Description Returns the API keys of the specified domain. Secrets are never returned.
+// @ID apikey-list
+// @Tags API Key Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} responses.APIKeyResponse
+// @Failure 400 {object} api.Response
+// @Router /api/apikey [get]
+func (h *APIKeyHandler) List(e echo.Context) error {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing domain information"))
+	}
+	domain, _ := d.(*models.Domain)
+
+	var apiKeys []models.APIKey
+	if err := h.apiKeyService.GetKeysInDomain(&apiKeys, domain); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to fetch API keys"))
+	}
+	return api.WebResponse(e, http.StatusOK, responses.NewAPIKeyResponse(apiKeys))
+}
+
+// Create godoc
+// @Summary Create API key
+// @Description Creates an API key acting with the given role in the specified domain. The role must be one the caller holds.
+// @Description A key with a role granted to the caller for a limited time expires when the grant does.
+// @Description The secret is only part of this response, send it with the key as `X-API-Key` and `X-API-Secret` headers.
+// @ID apikey-create
+// @Tags API Key Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.CreateAPIKeyRequest true "API key creation data"
+// @Success 201 {object} responses.APIKeySecretResponse
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Router /api/apikey [post]
+func (h *APIKeyHandler) Create(e echo.Context) error {
+	createRequest, err := util.BindAndValidate[requests.CreateAPIKeyRequest](e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR(err.Error()))
+	}
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing domain information"))
+	}
+	domain, _ := d.(*models.Domain)
+	user, ok := e.Get("user").(*models.User)
+	if !ok || user == nil {
+		return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	subject, err := util.ExtractSubject(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("Subject not found in context"))
+	}
+
+	// A key never gets more access than its creator
+	roles, _ := h.Server.Casbin.GetRolesForUser(subject, domain.UUID.String())
+	grant, bounded := h.Server.RoleGrants.Grant(subject, createRequest.Role, domain.UUID.String())
+	if !util.Contains(roles, createRequest.Role) || bounded && !grant.ActiveAt(time.Now()) {
+		return api.WebResponse(e, http.StatusForbidden, api.CASBIN_UNAUTHORIZED("Cannot grant a role you do not hold"))
+	}
+
+	apiKey := models.APIKey{
+		BaseResource: models.BaseResource{Domain: domain.UUID},
+		Name:         createRequest.Name,
+		Role:         createRequest.Role,
+		UserID:       user.ID,
+		User:         *user,
+	}
+	if createRequest.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, createRequest.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	// nor keeps a role granted for a limited time beyond the grant
+	if bounded && (apiKey.ExpiresAt == nil || apiKey.ExpiresAt.After(grant.ValidUntil)) {
+		apiKey.ExpiresAt = &grant.ValidUntil
+	}
+
+	secret, err := h.apiKeyService.Create(&apiKey)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+
+	log.Info().Str("event", "api_key_created").Uint64("user_id", user.ID).Str("api_key", apiKey.UUID.String()).
+		Str("role", apiKey.Role).Msg("API key created")
+	return api.WebResponse(e, http.StatusCreated, responses.NewAPIKeySecretResponse(apiKey, secret))
+}
+
+// Read godoc
+// @Summary Get API key
+// @Description Returns an API key of the specified domain. The secret is never returned.
+// @ID apikey-read
+// @Tags API Key Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "API key UUID"
+// @Success 200 {object} responses.APIKeyResponse
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /api/apikey/{uuid} [get]
+func (h *APIKeyHandler) Read(e echo.Context) error {
+	apiKey, err := h.findKeyInDomain(e)
+	if err != nil {
+		return api.WebResponse(e, statusFor(err), err)
+	}
+
+	res := *responses.NewAPIKeyResponse([]models.APIKey{*apiKey})
+	return api.WebResponse(e, http.StatusOK, res[0])
+}
+
+// Delete godoc
+// @Summary Revoke API key
+// @Description Revokes an API key of the specified domain, requests using it are rejected immediately.
+// @ID apikey-delete
+// @Tags API Key Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "API key UUID"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /api/apikey/{uuid} [delete]
+func (h *APIKeyHandler) Delete(e echo.Context) error {
+	apiKey, err := h.findKeyInDomain(e)
+	if err != nil {
+		return api.WebResponse(e, statusFor(err), err)
+	}
+
+	if err := h.apiKeyService.Revoke(apiKey); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to revoke API key"))
+	}
+
+	details := map[string]interface{}{"api_key": apiKey.UUID.String(), "name": apiKey.Name}
+	if subject, err := util.ExtractSubject(e); err == nil {
+		details["revoked_by"] = subject
+	}
+	h.auditService.Record(services.AuditEvent{
+		Event:   "api_key_revoked",
+		UserID:  apiKey.UserID,
+		Details: details,
+	})
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("API key revoked"))
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	"goweb/api"
 	"goweb/models"
 	"goweb/requests"
@@ -10,6 +9,7 @@ import (
 	"goweb/services"
 	"goweb/util"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,42 +19,55 @@ import (
 
 // AuthHandler provides endpoints for user authentication, token refresh, and logout operations.
 type AuthHandler struct {
-	server       *server.Server
-	userService  *services.UserService
-	tokenService *services.TokenService
+	server         *server.Server
+	userService    *services.UserService
+	tokenService   *services.TokenService
+	mfaService     *services.MFAService
+	passkeyService *services.PasskeyService
+	loginThrottle  *services.LoginThrottleService
 }
 
 // NewAuthHandler initializes the AuthHandler with the provided server and its dependencies.
 func NewAuthHandler(server *server.Server) *AuthHandler {
 	return &AuthHandler{
-		server:       server,
-		userService:  services.NewUserService(server.DB),
-		tokenService: services.NewTokenService(server),
+		server:         server,
+		userService:    services.NewUserService(server.DB),
+		tokenService:   services.NewTokenService(server),
+		mfaService:     services.NewMFAService(server),
+		passkeyService: services.NewPasskeyService(server),
+		loginThrottle:  services.NewLoginThrottleService(server),
 	}
 }
 
 // Helper to generate token pair and return response
-func (h *AuthHandler) respondWithTokenPair(c echo.Context, user *models.User) error {
-	accessToken, refreshToken, exp, err := h.tokenService.GenerateTokenPair(user)
+func (h *AuthHandler) respondWithTokenPair(c echo.Context, user *models.User, mfa bool) error {
+	accessToken, refreshToken, exp, err := h.tokenService.GenerateTokenPair(user, services.NewClientInfo(c), mfa)
 	if err != nil {
 		log.Error().Str("event", "token_generation_failed").Err(err).Uint64("user_id", uint64(user.ID)).Msg("Failed to generate authentication tokens")
 		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to generate authentication tokens"))
 	}
+	// The failures of the account are only cleared once the login is complete, not after its first factor
+	h.loginThrottle.RecordSuccess(user.Email)
 	res := responses.NewLoginResponse(accessToken, refreshToken, exp)
 	return api.WebResponse(c, http.StatusOK, res)
 }
 
 // Login godoc
 // @Summary Authenticates a user using email and password, and returns a token pair if successful.
-// @Description Perform user login with email and password. The returned `accessToken` should be used as a Bearer token in the `Authorization` header (i.e., `Authorization: Bearer <accessToken>`) for authenticated endpoints such as Logout.
+// @Description Perform user login with email and password. The returned `accessToken` should be used as a Bearer token in the `Authorization` header (i.e., `Authorization: Bearer <accessToken>`) for authenticated endpoints such as Logout. Every login starts its own session, the optional `X-Device-Name` header names the device of that session.
+// @Description Users with two-factor authentication get a 202 with an `mfa_token` instead, exchange it with a code at `/login/mfa`.
+// @Description Each failed attempt doubles the wait before the next one, after too many failures of an account or IP the login is locked for a while. Both are answered with a 429 and a `Retry-After` header, lockouts with the code `100017`.
 // @ID user-login
 // @Tags Account Actions
 // @Accept json
 // @Produce json
 // @Param params body requests.LoginRequest true "User's credentials"
+// @Param X-Device-Name header string false "Name of the device the session is created for"
 // @Success 200 {object} responses.LoginResponse
+// @Success 202 {object} responses.MFAChallengeResponse
 // @Failure 400 {object} api.Response
 // @Failure 401 {object} api.Response
+// @Failure 429 {object} api.Response
 // @Failure 500 {object} api.Response
 // @Router /login [post]
 func (h *AuthHandler) Login(c echo.Context) error {
@@ -65,24 +78,170 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return api.WebResponse(c, http.StatusBadRequest, err)
 	}
 
+	if allowed, err := h.checkLoginThrottle(c, loginRequest.Email); !allowed {
+		return err
+	}
+
 	user := &models.User{}
 	if err := h.userService.GetUserByEmail(user, loginRequest.Email); err != nil || user.ID == 0 {
 		log.Info().Str("event", "login_failed").Str("email", loginRequest.Email).Str("error", "user_not_found").Msg("Login failed: user not found")
+		h.recordLoginFailure(c, loginRequest.Email)
 		return api.WebResponse(c, http.StatusUnauthorized, api.INVALID_CREDENTIALS())
 	}
 
 	if err := util.CheckPasswordHash(user.Password, loginRequest.Password); err != nil {
 		log.Info().Str("event", "login_failed").Str("email", loginRequest.Email).Uint64("user_id", uint64(user.ID)).Str("error", "invalid_password").Msg("Login failed: invalid password")
+		h.recordLoginFailure(c, loginRequest.Email)
 		return api.WebResponse(c, http.StatusUnauthorized, api.INVALID_CREDENTIALS())
 	}
 
+	if user.MFAEnabled {
+		return h.respondWithMFAChallenge(c, user)
+	}
+
 	log.Info().Str("event", "login_success").Uint64("user_id", uint64(user.ID)).Str("email", user.Email).Int64("duration_ms", time.Since(start).Milliseconds()).Msg("Login successful")
-	return h.respondWithTokenPair(c, user)
+	return h.respondWithTokenPair(c, user, false)
+}
+
+// checkLoginThrottle answers the request with a 429 while the account or IP has to wait, it reports whether the login may go on
+func (h *AuthHandler) checkLoginThrottle(c echo.Context, email string) (bool, error) {
+	wait, err := h.loginThrottle.Check(email, c.RealIP())
+	if err == nil {
+		return true, nil
+	}
+	if res, ok := err.(api.Response); ok && res.Code == api.CodeInternalServiceError {
+		log.Error().Str("event", "login_throttle_failed").Err(err).Msg("Failed to check login attempts")
+		return false, api.WebResponse(c, http.StatusInternalServerError, err)
+	}
+	log.Info().Str("event", "login_throttled").Str("email", email).Str("ip", c.RealIP()).Str("error", err.Error()).Msg("Login rejected: too many failed attempts")
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+	return false, api.WebResponse(c, http.StatusTooManyRequests, err)
+}
+
+// recordLoginFailure counts a failed password or second factor login towards the backoff and lockout of the account and IP
+func (h *AuthHandler) recordLoginFailure(c echo.Context, email string) {
+	locked, err := h.loginThrottle.RecordFailure(email, c.RealIP())
+	if err != nil {
+		log.Error().Str("event", "login_throttle_failed").Err(err).Msg("Failed to record failed login")
+		return
+	}
+	if locked {
+		log.Warn().Str("event", "login_locked").Str("email", email).Str("ip", c.RealIP()).Msg("Login locked after too many failed attempts")
+	}
+}
+
+// respondWithMFAChallenge starts the second login step for users with two-factor authentication
+func (h *AuthHandler) respondWithMFAChallenge(c echo.Context, user *models.User) error {
+	token, exp, err := h.mfaService.CreatePendingLogin(user)
+	if err != nil {
+		log.Error().Str("event", "mfa_challenge_failed").Err(err).Uint64("user_id", user.ID).Msg("Failed to start two-factor login")
+		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to start two-factor login"))
+	}
+
+	log.Info().Str("event", "login_mfa_challenge").Uint64("user_id", user.ID).Msg("Password accepted, second factor required")
+	return api.WebResponse(c, http.StatusAccepted, responses.NewMFAChallengeResponse(token, exp))
+}
+
+// LoginMFA godoc
+// @Summary Completes a two-factor login
+// @Description Exchanges the `mfa_token` returned by login and a TOTP or recovery code for a token pair. The `mfa_token` is invalidated after 5 wrong codes.
+// @Description Wrong codes count towards the backoff and lockout of the account and IP like wrong passwords, both are answered with a 429 and a `Retry-After` header.
+// @ID user-login-mfa
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Param params body requests.MFALoginRequest true "MFA token and code"
+// @Param X-Device-Name header string false "Name of the device the session is created for"
+// @Success 200 {object} responses.LoginResponse
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /login/mfa [post]
+func (h *AuthHandler) LoginMFA(c echo.Context) error {
+	mfaRequest, err := util.BindAndValidate[requests.MFALoginRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	// Codes are guessed against the same backoff and lockout as passwords, a new pending login starts no new budget
+	pending, err := h.mfaService.PendingLoginUser(mfaRequest.MFAToken)
+	if err != nil {
+		log.Info().Str("event", "login_mfa_failed").Str("ip", c.RealIP()).Str("error", err.Error()).Msg("Login failed: second factor rejected")
+		return api.WebResponse(c, http.StatusUnauthorized, err)
+	}
+	if allowed, err := h.checkLoginThrottle(c, pending.Email); !allowed {
+		return err
+	}
+
+	user, err := h.mfaService.CompletePendingLogin(mfaRequest.MFAToken, mfaRequest.Code)
+	if err != nil {
+		log.Info().Str("event", "login_mfa_failed").Uint64("user_id", pending.ID).Str("ip", c.RealIP()).Str("error", err.Error()).Msg("Login failed: second factor rejected")
+		h.recordLoginFailure(c, pending.Email)
+		return api.WebResponse(c, http.StatusUnauthorized, err)
+	}
+
+	log.Info().Str("event", "login_success").Uint64("user_id", user.ID).Str("email", user.Email).Bool("mfa", true).Msg("Login successful")
+	return h.respondWithTokenPair(c, user, true)
+}
+
+// LoginPasskeyBegin godoc
+// @Summary Starts a passkey login
+// @Description Returns the options to pass to `navigator.credentials.get` and a session to send with its result to `/login/passkey/finish` within 5 minutes. No email is needed, the user is identified by the passkey.
+// @ID user-login-passkey-begin
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.PasskeyCeremonyResponse
+// @Failure 500 {object} api.Response
+// @Router /login/passkey/begin [post]
+func (h *AuthHandler) LoginPasskeyBegin(c echo.Context) error {
+	assertion, session, exp, err := h.passkeyService.BeginLogin()
+	if err != nil {
+		return api.WebResponse(c, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(c, http.StatusOK, responses.NewPasskeyCeremonyResponse(session, assertion, exp))
+}
+
+// LoginPasskeyFinish godoc
+// @Summary Completes a passkey login
+// @Description Verifies the assertion of the authenticator and returns a token pair. Passkeys verifying the user with a PIN or biometrics count as two-factor authentication,
+// @Description users with two-factor authentication get a 202 with an `mfa_token` for `/login/mfa` otherwise.
+// @ID user-login-passkey-finish
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Param params body requests.PasskeyLoginRequest true "Passkey session and assertion"
+// @Param X-Device-Name header string false "Name of the device the session is created for"
+// @Success 200 {object} responses.LoginResponse
+// @Success 202 {object} responses.MFAChallengeResponse
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /login/passkey/finish [post]
+func (h *AuthHandler) LoginPasskeyFinish(c echo.Context) error {
+	passkeyRequest, err := util.BindAndValidate[requests.PasskeyLoginRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	user, userVerified, err := h.passkeyService.FinishLogin(passkeyRequest.Session, passkeyRequest.Credential)
+	if err != nil {
+		log.Info().Str("event", "login_failed").Str("ip", c.RealIP()).Str("method", "passkey").Str("error", err.Error()).Msg("Login failed: passkey rejected")
+		return api.WebResponse(c, passkeyStatusFor(err), err)
+	}
+
+	if user.MFAEnabled && !userVerified {
+		return h.respondWithMFAChallenge(c, user)
+	}
+
+	log.Info().Str("event", "login_success").Uint64("user_id", user.ID).Str("email", user.Email).Str("method", "passkey").Bool("mfa", userVerified).Msg("Login successful")
+	return h.respondWithTokenPair(c, user, userVerified)
 }
 
 // RefreshToken godoc
 // @Summary Refresh access token
-// @Description RefreshToken issues a new access token using a valid refresh token.
+// @Description RefreshToken issues a new token pair using a valid refresh token. Each refresh token can be used only once, reusing a rotated refresh token revokes all tokens of its login.
 // @ID user-refresh
 // @Tags Account Actions
 // @Accept json
@@ -101,25 +260,26 @@ func (h *AuthHandler) RefreshToken(c echo.Context) error {
 		return api.WebResponse(c, http.StatusBadRequest, err)
 	}
 
-	claims, err := h.tokenService.ParseToken(refreshRequest.Token, h.server.Config.Auth.RefreshSecret)
+	claims, err := h.tokenService.ParseToken(refreshRequest.Token, h.server.RefreshKeys)
 	if err != nil {
 		log.Info().Str("event", "refresh_token_invalid").Str("error", err.Error()).Msg("Invalid or expired refresh token")
 		return api.WebResponse(c, http.StatusUnauthorized, api.INVALID_TOKEN("Invalid or expired refresh token"))
 	}
 
-	user, err := h.tokenService.ValidateToken(claims, true)
-	if err != nil || user.ID == 0 {
-		log.Info().Str("event", "refresh_token_validation_failed").Uint64("user_id", claims.UserID).Str("error", err.Error()).Msg("Refresh token validation failed")
+	// Rotation invalidates the presented refresh token, reusing it revokes the token family
+	user, accessToken, refreshToken, exp, err := h.tokenService.RotateTokenPair(claims, services.NewClientInfo(c))
+	if err != nil {
+		log.Info().Str("event", "refresh_token_validation_failed").Uint64("user_id", claims.UserID).Str("family", claims.Family).Str("ip", c.RealIP()).Str("error", err.Error()).Msg("Refresh token validation failed")
 		return api.WebResponse(c, http.StatusUnauthorized, api.INVALID_TOKEN(err.Error()))
 	}
 
 	log.Info().Str("event", "token_refresh_success").Uint64("user_id", uint64(user.ID)).Int64("duration_ms", time.Since(start).Milliseconds()).Msg("Token refresh successful")
-	return h.respondWithTokenPair(c, user)
+	return api.WebResponse(c, http.StatusOK, responses.NewLoginResponse(accessToken, refreshToken, exp))
 }
 
 // Logout godoc
 // @Summary Logout user
-// @Description Invalidate user session and logout
+// @Description Invalidate the current session and logout, sessions on other devices stay active
 // @ID user-logout
 // @Tags Account Actions
 // @Accept json
@@ -141,12 +301,12 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 		return api.WebResponse(c, http.StatusUnauthorized, api.INVALID_TOKEN("Invalid token claims"))
 	}
 
-	err := h.server.Redis.Del(c.Request().Context(), fmt.Sprintf("token-%d", claims.UserID)).Err()
+	err := h.tokenService.RevokeSession(claims.UserID, claims.Family)
 	if err != nil {
 		log.Error().Str("event", "logout_redis_failed").Err(err).Uint64("user_id", claims.UserID).Msg("Failed to logout user (redis error)")
 		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to logout user"))
 	}
 
-	log.Info().Str("event", "logout_success").Uint64("user_id", claims.UserID).Msg("Logout successful")
+	log.Info().Str("event", "logout_success").Uint64("user_id", claims.UserID).Str("session", claims.Family).Msg("Logout successful")
 	return api.WebResponse(c, http.StatusOK, api.USER_LOGGED_OUT())
 }
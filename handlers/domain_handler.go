@@ -4,12 +4,15 @@ import (
 	"goweb/api"
 	"goweb/models"
 	"goweb/requests"
+	"goweb/responses"
 	"goweb/server"
 	"goweb/services"
+	"goweb/util"
 	"net/http"
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 )
 
 // DomainHandler provides endpoints for managing domains, including CRUD operations (some not implemented).
@@ -125,3 +128,57 @@ func (u DomainHandler) Update(e echo.Context) error {
 func (u DomainHandler) Delete(e echo.Context) error {
 	return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("Delete Domain not implemented"))
 }
+
+// ReadSettings godoc
+// @Summary Get domain settings
+// @Description Returns the settings of the specified domain.
+// @ID domain-settings-read
+// @Tags Domain Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} responses.DomainSettingsResponse
+// @Failure 400 {object} api.Response
+// @Router /api/domain/settings [get]
+func (u DomainHandler) ReadSettings(e echo.Context) error {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing domain information"))
+	}
+	domain, _ := d.(*models.Domain)
+
+	return api.WebResponse(e, http.StatusOK, responses.NewDomainSettingsResponse(domain))
+}
+
+// UpdateSettings godoc
+// @Summary Update domain settings
+// @Description Updates the settings of the specified domain. With `require_mfa` members can only access the domain with tokens from a two-factor login, with `require_verified_email` only after verifying their email.
+// @ID domain-settings-update
+// @Tags Domain Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.DomainSettingsRequest true "Domain settings"
+// @Success 200 {object} responses.DomainSettingsResponse
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/domain/settings [put]
+func (u DomainHandler) UpdateSettings(e echo.Context) error {
+	settingsRequest, err := util.BindAndValidate[requests.DomainSettingsRequest](e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Invalid request format"))
+	}
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing domain information"))
+	}
+	domain, _ := d.(*models.Domain)
+
+	domainService := services.NewDomainService(u.server.DB)
+	if err := domainService.UpdateSettings(domain, settingsRequest); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to update domain settings"))
+	}
+
+	log.Info().Str("event", "domain_settings_updated").Str("domain", domain.UUID.String()).Bool("require_mfa", domain.RequireMFA).Msg("Domain settings updated")
+	return api.WebResponse(e, http.StatusOK, responses.NewDomainSettingsResponse(domain))
+}
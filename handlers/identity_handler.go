@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/responses"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// IdentityHandler provides endpoints for the current user to manage linked social and OpenID Connect accounts.
+// Linking starts at SocialHandler.Link, as it runs through the provider's login.
+type IdentityHandler struct {
+	server          *server.Server
+	identityService *services.IdentityService
+}
+
+// NewIdentityHandler initializes the IdentityHandler with the provided server and its dependencies.
+func NewIdentityHandler(server *server.Server) *IdentityHandler {
+	return &IdentityHandler{
+		server:          server,
+		identityService: services.NewIdentityService(server),
+	}
+}
+
+// List godoc
+// @Summary List my linked accounts
+// @Description Returns the social and OpenID Connect accounts linked to the current user.
+// @ID identity-list
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} responses.IdentityResponse
+// @Failure 401 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /identities [get]
+func (h *IdentityHandler) List(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+
+	identities, err := h.identityService.ListForUser(user.ID)
+	if err != nil {
+		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to fetch linked accounts"))
+	}
+	return api.WebResponse(c, http.StatusOK, responses.NewIdentityResponse(identities))
+}
+
+// Unlink godoc
+// @Summary Unlink account
+// @Description Unlinks a social or OpenID Connect account from the current user, it can no longer be used to log in. The last way to log in cannot be unlinked: set a password, add a passkey or link another account first.
+// @ID identity-unlink
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "Linked account UUID"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /identities/{uuid} [delete]
+func (h *IdentityHandler) Unlink(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	uuid, err := util.GetUUIDParam(c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing or invalid UUID parameter"))
+	}
+
+	identity, err := h.identityService.Unlink(user.ID, uuid)
+	if err != nil {
+		return api.WebResponse(c, identityStatusFor(err), err)
+	}
+
+	log.Info().Str("event", "identity_unlinked").Uint64("user_id", user.ID).Str("provider", identity.Provider).Str("identity", uuid).Msg("Account unlinked")
+	return api.WebResponse(c, http.StatusOK, api.RESOURCE_DELETED("Account unlinked"))
+}
+
+// identityStatusFor maps the api response code of an identity change to the HTTP status
+func identityStatusFor(err error) int {
+	if res, ok := err.(api.Response); ok {
+		switch res.Code {
+		case api.CodeLastLoginMethod:
+			return http.StatusConflict
+		case api.CodeResourceNotFound, api.CodeUserNotFound:
+			return http.StatusNotFound
+		}
+	}
+	return http.StatusInternalServerError
+}
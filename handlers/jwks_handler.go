@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"goweb/server"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// JWKSHandler publishes the public keys used to sign access tokens.
+type JWKSHandler struct {
+	server *server.Server
+}
+
+// NewJWKSHandler initializes the JWKSHandler with the provided server.
+func NewJWKSHandler(server *server.Server) *JWKSHandler {
+	return &JWKSHandler{server: server}
+}
+
+// JWKS godoc
+// @Summary Access token signing keys
+// @Description Returns the JSON Web Key Set other services use to verify access tokens by their `kid` header. Empty when tokens are signed with a shared secret (HS512).
+// @ID jwks
+// @Tags Account Actions
+// @Produce json
+// @Success 200 {object} keys.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.server.AccessKeys.JWKS())
+}
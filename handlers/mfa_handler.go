@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/requests"
+	"goweb/responses"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// MFAHandler provides endpoints for the current user to manage TOTP two-factor authentication.
+type MFAHandler struct {
+	server     *server.Server
+	mfaService *services.MFAService
+}
+
+// NewMFAHandler initializes the MFAHandler with the provided server and its dependencies.
+func NewMFAHandler(server *server.Server) *MFAHandler {
+	return &MFAHandler{
+		server:     server,
+		mfaService: services.NewMFAService(server),
+	}
+}
+
+// currentUser returns the authenticated user from context
+func currentUser(c echo.Context) (*models.User, bool) {
+	user, ok := c.Get("user").(*models.User)
+	return user, ok && user != nil
+}
+
+// Enroll godoc
+// @Summary Start TOTP enrollment
+// @Description Generates a TOTP secret and its `otpauth://` provisioning URI (render it as QR code for authenticator apps). Two-factor authentication is enabled once a code is confirmed within 10 minutes.
+// @ID mfa-totp-enroll
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} responses.TOTPEnrollmentResponse
+// @Failure 401 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Router /mfa/totp [post]
+func (h *MFAHandler) Enroll(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+
+	secret, uri, err := h.mfaService.BeginEnrollment(user)
+	if err != nil {
+		if res, ok := err.(api.Response); ok && res.Code == api.CodeResourceExists {
+			return api.WebResponse(c, http.StatusConflict, err)
+		}
+		return api.WebResponse(c, http.StatusInternalServerError, err)
+	}
+
+	return api.WebResponse(c, http.StatusOK, responses.TOTPEnrollmentResponse{Secret: secret, URI: uri})
+}
+
+// Confirm godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enables two-factor authentication with the first code of the authenticator and returns 10 single-use recovery codes. Sign in again to get tokens satisfying domains that require two-factor authentication.
+// @ID mfa-totp-confirm
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.MFACodeRequest true "TOTP code"
+// @Success 200 {object} responses.RecoveryCodesResponse
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /mfa/totp/confirm [post]
+func (h *MFAHandler) Confirm(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	codeRequest, err := util.BindAndValidate[requests.MFACodeRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(user, codeRequest.Code)
+	if err != nil {
+		return api.WebResponse(c, mfaStatusFor(err), err)
+	}
+
+	log.Info().Str("event", "mfa_enabled").Uint64("user_id", user.ID).Msg("Two-factor authentication enabled")
+	return api.WebResponse(c, http.StatusOK, responses.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable godoc
+// @Summary Disable TOTP
+// @Description Disables two-factor authentication after verifying a TOTP or recovery code.
+// @ID mfa-totp-disable
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Router /mfa/totp [delete]
+func (h *MFAHandler) Disable(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	codeRequest, err := util.BindAndValidate[requests.MFACodeRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	if err := h.mfaService.Disable(user, codeRequest.Code); err != nil {
+		return api.WebResponse(c, mfaStatusFor(err), err)
+	}
+
+	log.Info().Str("event", "mfa_disabled").Uint64("user_id", user.ID).Msg("Two-factor authentication disabled")
+	return api.WebResponse(c, http.StatusOK, api.RESOURCE_DELETED("Two-factor authentication disabled"))
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes after verifying a TOTP or recovery code, previous codes stop working.
+// @ID mfa-recovery-codes
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} responses.RecoveryCodesResponse
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Router /mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	codeRequest, err := util.BindAndValidate[requests.MFACodeRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(user, codeRequest.Code)
+	if err != nil {
+		return api.WebResponse(c, mfaStatusFor(err), err)
+	}
+
+	log.Info().Str("event", "mfa_recovery_codes_regenerated").Uint64("user_id", user.ID).Msg("Recovery codes regenerated")
+	return api.WebResponse(c, http.StatusOK, responses.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// mfaStatusFor maps the api response code of an MFA operation to the HTTP status
+func mfaStatusFor(err error) int {
+	if res, ok := err.(api.Response); ok {
+		switch res.Code {
+		case api.CodeInvalidCredentials:
+			return http.StatusUnauthorized
+		case api.CodeResourceNotFound:
+			return http.StatusNotFound
+		case api.CodeInternalServiceError:
+			return http.StatusInternalServerError
+		}
+	}
+	return http.StatusBadRequest
+}
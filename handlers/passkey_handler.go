@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/requests"
+	"goweb/responses"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// PasskeyHandler provides endpoints for the current user to register and manage passkeys.
+type PasskeyHandler struct {
+	server         *server.Server
+	passkeyService *services.PasskeyService
+}
+
+// NewPasskeyHandler initializes the PasskeyHandler with the provided server and its dependencies.
+func NewPasskeyHandler(server *server.Server) *PasskeyHandler {
+	return &PasskeyHandler{
+		server:         server,
+		passkeyService: services.NewPasskeyService(server),
+	}
+}
+
+// BeginRegistration godoc
+// @Summary Start passkey registration
+// @Description Returns the options to pass to `navigator.credentials.create` and a session to send with its result to `/passkeys/register/finish` within 5 minutes.
+// @ID passkey-register-begin
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} responses.PasskeyCeremonyResponse
+// @Failure 401 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /passkeys/register/begin [post]
+func (h *PasskeyHandler) BeginRegistration(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+
+	creation, session, exp, err := h.passkeyService.BeginRegistration(user)
+	if err != nil {
+		return api.WebResponse(c, passkeyStatusFor(err), err)
+	}
+
+	return api.WebResponse(c, http.StatusOK, responses.NewPasskeyCeremonyResponse(session, creation, exp))
+}
+
+// FinishRegistration godoc
+// @Summary Finish passkey registration
+// @Description Verifies the credential created by the authenticator and stores it as passkey of the current user.
+// @ID passkey-register-finish
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.PasskeyRegistrationRequest true "Passkey session and created credential"
+// @Success 201 {object} responses.PasskeyResponse
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /passkeys/register/finish [post]
+func (h *PasskeyHandler) FinishRegistration(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	registrationRequest, err := util.BindAndValidate[requests.PasskeyRegistrationRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	credential, err := h.passkeyService.FinishRegistration(user, registrationRequest.Session, registrationRequest.Name, registrationRequest.Credential)
+	if err != nil {
+		log.Info().Str("event", "passkey_registration_failed").Uint64("user_id", user.ID).Str("error", err.Error()).Msg("Passkey registration failed")
+		return api.WebResponse(c, passkeyStatusFor(err), err)
+	}
+
+	log.Info().Str("event", "passkey_registered").Uint64("user_id", user.ID).Str("passkey", credential.UUID.String()).Msg("Passkey registered")
+	res := *responses.NewPasskeyResponse([]models.WebAuthnCredential{*credential})
+	return api.WebResponse(c, http.StatusCreated, res[0])
+}
+
+// List godoc
+// @Summary List my passkeys
+// @Description Returns the passkeys of the current user.
+// @ID passkey-list
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} responses.PasskeyResponse
+// @Failure 401 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /passkeys [get]
+func (h *PasskeyHandler) List(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+
+	credentials, err := h.passkeyService.ListForUser(user.ID)
+	if err != nil {
+		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to fetch passkeys"))
+	}
+	return api.WebResponse(c, http.StatusOK, responses.NewPasskeyResponse(credentials))
+}
+
+// Delete godoc
+// @Summary Delete passkey
+// @Description Deletes a passkey of the current user, it can no longer be used to log in. The last passkey of an account without a password or linked account can not be deleted.
+// @ID passkey-delete
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "Passkey UUID"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Router /passkeys/{uuid} [delete]
+func (h *PasskeyHandler) Delete(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	uuid, err := util.GetUUIDParam(c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing or invalid UUID parameter"))
+	}
+
+	if err := h.passkeyService.Delete(user.ID, uuid); err != nil {
+		return api.WebResponse(c, passkeyStatusFor(err), err)
+	}
+
+	log.Info().Str("event", "passkey_deleted").Uint64("user_id", user.ID).Str("passkey", uuid).Msg("Passkey deleted")
+	return api.WebResponse(c, http.StatusOK, api.RESOURCE_DELETED("Passkey deleted"))
+}
+
+// passkeyStatusFor maps the api response code of a passkey ceremony or change to the HTTP status
+func passkeyStatusFor(err error) int {
+	if res, ok := err.(api.Response); ok {
+		switch res.Code {
+		case api.CodeInvalidToken:
+			return http.StatusUnauthorized
+		case api.CodeLastLoginMethod:
+			return http.StatusConflict
+		case api.CodeUserNotFound:
+			return http.StatusNotFound
+		}
+	}
+	return mfaStatusFor(err)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/requests"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// PasswordHandler provides endpoints for users to recover a forgotten password.
+type PasswordHandler struct {
+	server          *server.Server
+	passwordService *services.PasswordService
+}
+
+// NewPasswordHandler initializes the PasswordHandler with the provided server and its dependencies.
+func NewPasswordHandler(server *server.Server) *PasswordHandler {
+	return &PasswordHandler{
+		server:          server,
+		passwordService: services.NewPasswordService(server),
+	}
+}
+
+// Forgot godoc
+// @Summary Request a password reset
+// @Description Mails a link with a single-use reset token valid for 30 minutes. The response is the same whether or not the email belongs to an account, requesting again invalidates the previous token.
+// @ID password-forgot
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Param params body requests.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /password/forgot [post]
+func (h *PasswordHandler) Forgot(c echo.Context) error {
+	forgotRequest, err := util.BindAndValidate[requests.ForgotPasswordRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	if err := h.passwordService.RequestReset(forgotRequest.Email); err != nil {
+		log.Error().Str("event", "password_reset_request_failed").Err(err).Str("email", forgotRequest.Email).Msg("Failed to request password reset")
+		return api.WebResponse(c, http.StatusInternalServerError, err)
+	}
+
+	log.Info().Str("event", "password_reset_requested").Str("email", forgotRequest.Email).Str("ip", c.RealIP()).Msg("Password reset requested")
+	return api.WebResponse(c, http.StatusOK, api.STATUS_OK("If the email belongs to an account, a reset link was sent"))
+}
+
+// Reset godoc
+// @Summary Reset password
+// @Description Sets a new password with a reset token. The token is invalidated and all sessions of the user are revoked, sign in again with the new password.
+// @ID password-reset
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Param params body requests.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /password/reset [post]
+func (h *PasswordHandler) Reset(c echo.Context) error {
+	resetRequest, err := util.BindAndValidate[requests.ResetPasswordRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	user, err := h.passwordService.ResetPassword(resetRequest.Token, resetRequest.Password)
+	if err != nil {
+		log.Info().Str("event", "password_reset_failed").Str("ip", c.RealIP()).Str("error", err.Error()).Msg("Password reset failed")
+		if res, ok := err.(api.Response); ok && res.Code == api.CodeInternalServiceError {
+			return api.WebResponse(c, http.StatusInternalServerError, err)
+		}
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	log.Info().Str("event", "password_reset").Uint64("user_id", user.ID).Str("ip", c.RealIP()).Msg("Password reset, all sessions revoked")
+	return api.WebResponse(c, http.StatusOK, api.STATUS_OK("Password changed, sign in again"))
+}
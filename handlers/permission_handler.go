@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/requests"
+	"goweb/responses"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PermissionHandler provides endpoints for managing the permissions of the roles within a domain.
+type PermissionHandler struct {
+	Server       *server.Server
+	service      *services.PolicyService
+	auditService *services.AuditService
+}
+
+// NewPermissionHandler initializes the PermissionHandler with the provided server and its dependencies.
+func NewPermissionHandler(server *server.Server) *PermissionHandler {
+	return &PermissionHandler{
+		Server:       server,
+		service:      services.NewPolicyService(server),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// Type returns the string identifier for the PermissionHandler.
+func (h *PermissionHandler) Type() string {
+	return "Permission"
+}
+
+// List godoc
+// @Summary List permissions
+// @Description Returns the permissions of the roles in the specified domain, optionally of one role only. Roles without permissions of their own in the domain are listed with the templates of the System domain, marked as inherited.
+// @ID permission-list
+// @Tags Permission Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param role query string false "Only permissions of this role"
+// @Success 200 {array} responses.PermissionResponse
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/permission [get]
+func (h *PermissionHandler) List(e echo.Context) error {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing domain information"))
+	}
+	domain, _ := d.(*models.Domain)
+
+	policies, err := h.service.List(domain, e.QueryParam("role"))
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(e, http.StatusOK, responses.NewPermissionResponse(policies, domain.UUID.String()))
+}
+
+// Create godoc
+// @Summary Grant permission
+// @Description Allows a role of the specified domain an action on an object type, e.g. Manager may Create Post. With the condition "owner" the action is only allowed on the subject's own resources, e.g. Operator may Update their own Posts. Only permissions the caller holds can be granted. Granting a permission to a role inheriting the System domain's template copies the template into the domain first.
+// @ID permission-create
+// @Tags Permission Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.PermissionRequest true "Permission to grant"
+// @Success 201 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/permission [post]
+func (h *PermissionHandler) Create(e echo.Context) error {
+	request, domain, subject, err := h.bindPermission(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, err)
+	}
+
+	if err := h.service.Add(subject, domain, request); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
+
+	h.record(e, "permission_granted", subject, domain, request)
+	return api.WebResponse(e, http.StatusCreated, api.RESOURCE_CREATED("Permission granted"))
+}
+
+// Delete godoc
+// @Summary Revoke permission
+// @Description Removes an action on an object type from a role of the specified domain. Revoking an inherited permission copies the rest of the System domain's template into the domain.
+// @ID permission-delete
+// @Tags Permission Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param role query string true "Role"
+// @Param object query string true "Object type"
+// @Param action query string true "Action"
+// @Param condition query string false "Condition of a conditional permission"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/permission [delete]
+func (h *PermissionHandler) Delete(e echo.Context) error {
+	request, domain, subject, err := h.bindPermission(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, err)
+	}
+
+	if err := h.service.Remove(domain, request); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
+
+	h.record(e, "permission_revoked", subject, domain, request)
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("Permission revoked"))
+}
+
+// ResetOverride godoc
+// @Summary Reset role to template
+// @Description Removes the permissions a role of the specified domain defines itself, so the role inherits the template of the System domain again.
+// @ID permission-reset-override
+// @Tags Permission Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param role query string true "Role"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/permission/override [delete]
+func (h *PermissionHandler) ResetOverride(e echo.Context) error {
+	request, err := util.BindAndValidate[requests.UserRoleRequest](e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Invalid request format"))
+	}
+	domain, subject, err := h.caller(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, err)
+	}
+
+	if err := h.service.ResetOverride(domain, request.Role); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
+
+	h.record(e, "permission_override_reset", subject, domain, &requests.PermissionRequest{Role: request.Role})
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("Role inherits the System template again"))
+}
+
+// Explain godoc
+// @Summary Explain permission decision
+// @Description Decides whether a subject (user or API key UUID, or user email) may perform an action on an object type and returns the matching rule and the chain of roles leading to it. Subjects without a role or role grant in the domain are not found. The domain defaults to the specified domain, other domains require the Explain permission there as well. Conditional permissions decide on the owner, the UUID of the user the resource belongs to. With what_if the decision is made as if the proposed policies and roles were saved, nothing is changed.
+// @ID permission-explain
+// @Tags Permission Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.ExplainRequest true "Decision to explain"
+// @Success 200 {object} responses.ExplainResponse
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/permission/explain [post]
+func (h *PermissionHandler) Explain(e echo.Context) error {
+	request, err := util.BindAndValidate[requests.ExplainRequest](e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Invalid request format"))
+	}
+	domain, caller, err := h.caller(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, err)
+	}
+
+	if request.Domain != "" && request.Domain != domain.UUID.String() {
+		other := &models.Domain{}
+		if err := services.NewDomainService(h.Server.DB).GetDomainByUUID(other, request.Domain); err != nil {
+			return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("Domain not found"))
+		}
+		allowed, err := h.Server.Casbin.Enforce(caller, other.UUID.String(), h.Type(), "Explain")
+		if err != nil {
+			return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Casbin enforcement error"))
+		}
+		if !allowed {
+			return api.WebResponse(e, http.StatusForbidden, api.CASBIN_UNAUTHORIZED())
+		}
+		domain = other
+	}
+
+	subject, err := h.service.ResolveSubject(request.Subject, domain)
+	if err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
+	explanation, err := h.service.Explain(subject, domain, request.Object, request.Action, request.Owner, request.WhatIf)
+	if err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
+
+	return api.WebResponse(e, http.StatusOK, responses.ExplainResponse{
+		Allowed: explanation.Allowed,
+		Subject: subject,
+		Domain:  domain.UUID.String(),
+		Object:  request.Object,
+		Action:  request.Action,
+		Owner:   request.Owner,
+		Rule:    explanation.Rule,
+		Chain:   explanation.Chain,
+		Roles:   explanation.Roles,
+		WhatIf:  request.WhatIf != nil,
+	})
+}
+
+// Mine godoc
+// @Summary List my permissions
+// @Description Returns the actions on object types the caller may perform in the specified domain, through any of its roles including inherited ones. Meant for the frontend to decide which actions to offer.
+// @ID permission-mine
+// @Tags Permission Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} responses.EffectivePermissionResponse
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/me/permissions [get]
+func (h *PermissionHandler) Mine(e echo.Context) error {
+	domain, subject, err := h.caller(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, err)
+	}
+
+	policies, err := h.service.Effective(subject, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(e, http.StatusOK, responses.NewEffectivePermissionResponse(policies))
+}
+
+// CheckMine godoc
+// @Summary Check my permissions
+// @Description Decides for each action on an object type whether the caller may perform it in the specified domain. With an owner the check is for a resource of that user, which permissions limited to the own resources apply to. The results are in the order of the checks, at most 100 checks per request.
+// @ID permission-check-mine
+// @Tags Permission Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param params body requests.PermissionCheckRequest true "Permissions to check"
+// @Success 200 {array} responses.PermissionCheckResponse
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/me/permissions/check [post]
+func (h *PermissionHandler) CheckMine(e echo.Context) error {
+	request, err := util.BindAndValidate[requests.PermissionCheckRequest](e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Invalid request format"))
+	}
+	domain, subject, err := h.caller(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, err)
+	}
+
+	allowed, err := h.service.Check(subject, domain, request.Checks)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(e, http.StatusOK, responses.NewPermissionCheckResponse(request.Checks, allowed))
+}
+
+// bindPermission validates the permission request and extracts the domain and subject it applies to
+func (h *PermissionHandler) bindPermission(e echo.Context) (*requests.PermissionRequest, *models.Domain, string, error) {
+	request, err := util.BindAndValidate[requests.PermissionRequest](e)
+	if err != nil {
+		return nil, nil, "", api.FIELD_VALIDATION_ERROR("Invalid request format")
+	}
+	domain, subject, err := h.caller(e)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return request, domain, subject, nil
+}
+
+// caller extracts the domain of the request and the subject making it
+func (h *PermissionHandler) caller(e echo.Context) (*models.Domain, string, error) {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return nil, "", api.FIELD_VALIDATION_ERROR("Missing domain information")
+	}
+	domain, _ := d.(*models.Domain)
+	subject, err := util.ExtractSubject(e)
+	if err != nil {
+		return nil, "", api.FIELD_VALIDATION_ERROR("Missing subject information")
+	}
+	return domain, subject, nil
+}
+
+// record writes the audit event of a permission change
+func (h *PermissionHandler) record(e echo.Context, event, subject string, domain *models.Domain, request *requests.PermissionRequest) {
+	var userID uint64
+	if user, ok := currentUser(e); ok {
+		userID = user.ID
+	}
+	h.auditService.Record(services.AuditEvent{
+		Event:  event,
+		UserID: userID,
+		Details: map[string]interface{}{
+			"domain":     domain.UUID.String(),
+			"role":       request.Role,
+			"object":     request.Object,
+			"action":     request.Action,
+			"condition":  request.Condition,
+			"changed_by": subject,
+		},
+	})
+}
+
+// policyStatusFor maps the api response code of a permission, role or role assignment change to the HTTP status
+func policyStatusFor(err error) int {
+	if res, ok := err.(api.Response); ok {
+		switch res.Code {
+		case api.CodeCasbinUnauthorized:
+			return http.StatusForbidden
+		case api.CodeResourceNotFound:
+			return http.StatusNotFound
+		case api.CodeResourceExists, api.CodeTemplateOverride, api.CodeRoleCycle:
+			return http.StatusConflict
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -30,6 +30,27 @@ func NewPostHandler(server *server.Server) *PostHandler {
 	return handler
 }
 
+// authorize decides the action on the loaded post. Permissions limited to the subject's own resources
+// only allow it on posts the caller wrote.
+func (h *PostHandler) authorize(e echo.Context, post *models.Post, action string) error {
+	subject, err := util.ExtractSubject(e)
+	if err != nil {
+		return api.CASBIN_UNAUTHORIZED()
+	}
+	owner := ""
+	if post.User.ID != 0 {
+		owner = post.User.UUID.String()
+	}
+	allowed, err := h.server.Authorize(subject, post.Domain.String(), h.Type(), action, owner)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Casbin enforcement error")
+	}
+	if !allowed {
+		return api.CASBIN_UNAUTHORIZED("You can only change your own posts")
+	}
+	return nil
+}
+
 // Type returns the string identifier for the PostHandler.
 func (u PostHandler) Type() string {
 	return "Post"
@@ -127,7 +148,7 @@ func (h *PostHandler) Read(e echo.Context) error {
 
 // Update godoc
 // @Summary Update post
-// @Description Modifies the details of a post by UUID within the specified domain.
+// @Description Modifies the details of a post by UUID within the specified domain. Callers allowed to update only their own posts get 403 for posts of others.
 // @ID post-update
 // @Tags Post Management
 // @Accept json
@@ -137,6 +158,7 @@ func (h *PostHandler) Read(e echo.Context) error {
 // @Security ApiKeyAuth
 // @Success 200 {object} responses.PostResponse
 // @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
 // @Failure 404 {object} api.Response
 // @Router /api/post/{uuid} [put]
 func (h *PostHandler) Update(e echo.Context) error {
@@ -158,13 +180,16 @@ func (h *PostHandler) Update(e echo.Context) error {
 	if post.ID == 0 {
 		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("Post not found"))
 	}
+	if err := h.authorize(e, &post, "Update"); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
 	h.postService.Update(&post, updateRequest)
 	return api.WebResponse(e, http.StatusOK, responses.NewPostResponse([]models.Post{post}))
 }
 
 // Delete godoc
 // @Summary Delete post
-// @Description Removes a post by UUID from the specified domain.
+// @Description Removes a post by UUID from the specified domain. Callers allowed to delete only their own posts get 403 for posts of others.
 // @ID post-delete
 // @Tags Post Management
 // @Accept json
@@ -173,6 +198,7 @@ func (h *PostHandler) Update(e echo.Context) error {
 // @Security ApiKeyAuth
 // @Success 200 {object} api.Response
 // @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
 // @Failure 404 {object} api.Response
 // @Router /api/post/{uuid} [delete]
 func (h *PostHandler) Delete(e echo.Context) error {
@@ -190,6 +216,9 @@ func (h *PostHandler) Delete(e echo.Context) error {
 	if post.ID == 0 {
 		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("Post not found"))
 	}
+	if err := h.authorize(e, &post, "Delete"); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
 	h.postService.Delete(&post)
 	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("Post deleted successfully"))
 }
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"goweb/api"
+	"goweb/models"
 	"goweb/requests"
 	"goweb/server"
+	"goweb/services"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -21,12 +23,15 @@ func NewRegisterHandler(server *server.Server) *RegisterHandler {
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register creates a new user and domain based on the provided registration request.
+// @Description Register creates a new user in the System domain, like social sign-ups, and mails a link to verify the email.
 // @ID user-register
 // @Tags Account Actions
 // @Accept json
 // @Produce json
 // @Param params body requests.RegisterRequest true "User's email, user's password"
+// @Success 201 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
 // @Router /register [post]
 // Register function creates Domain & User - together as pair
 func (registerHandler *RegisterHandler) Register(c echo.Context) error {
@@ -40,8 +45,12 @@ func (registerHandler *RegisterHandler) Register(c echo.Context) error {
 		return api.WebResponse(c, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR())
 	}
 
-	//domain := c.Get("domain").(*models.Domain)
-	//return services.NewUserService(u.Server.DB).Register(e, registerRequest, domain)
+	domain := new(models.Domain)
+	if err := registerHandler.server.DB.Where("name = ?", models.SystemDomain).First(domain).Error; err != nil {
+		return api.WebResponse(c, http.StatusInternalServerError, api.RESOURCE_CREATION_FAILED("System domain not found"))
+	}
 
-	return api.WebResponse(c, http.StatusCreated, api.RESOURCE_CREATED("User successfully created"))
+	userService := services.NewUserService(registerHandler.server.DB)
+	userService.SetRedis(registerHandler.server)
+	return userService.Register(c, registerRequest, domain)
 }
@@ -4,6 +4,7 @@ import (
 	"goweb/api"
 	"goweb/models"
 	"goweb/requests"
+	"goweb/responses"
 	"goweb/server"
 	"goweb/services"
 	"goweb/util"
@@ -22,7 +23,7 @@ type RoleHandler struct {
 func NewRoleHandler(server *server.Server) *RoleHandler {
 	return &RoleHandler{
 		Server:  server,
-		service: services.NewRoleService(server.DB),
+		service: services.NewRoleService(server),
 	}
 }
 
@@ -59,6 +60,19 @@ func (h *RoleHandler) getRoleByUUID(uuid string, domain *models.Domain) (*models
 	return &role, nil
 }
 
+// roleResponse adds the parent and the direct and effective permissions to the role
+func (h *RoleHandler) roleResponse(role *models.Role, domain *models.Domain) (*responses.RoleResponse, error) {
+	direct, err := h.service.RolePolicies(role.Name, domain)
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	effective, err := h.service.EffectivePolicies(role.Name, domain)
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	return responses.NewRoleResponse(role, h.service.Parent(role, domain), direct, effective, domain.UUID.String()), nil
+}
+
 // Type returns the string identifier for the RoleHandler.
 func (h *RoleHandler) Type() string {
 	return "Role"
@@ -91,7 +105,7 @@ func (h *RoleHandler) List(e echo.Context) error {
 
 // Create godoc
 // @Summary Create role
-// @Description Creates a new role in the specified domain.
+// @Description Creates a new role in the specified domain, optionally inheriting the permissions of a parent role. Only parents whose permissions the caller holds can be inherited.
 // @ID role-create
 // @Tags Role Management
 // @Accept json
@@ -100,6 +114,9 @@ func (h *RoleHandler) List(e echo.Context) error {
 // @Param params body requests.RoleRequest true "Role creation data"
 // @Success 201 {object} api.Response
 // @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
 // @Router /api/role [post]
 func (h *RoleHandler) Create(e echo.Context) error {
 	roleRequest, err := h.validateRoleRequest(e)
@@ -112,8 +129,13 @@ func (h *RoleHandler) Create(e echo.Context) error {
 		return api.WebResponse(e, http.StatusBadRequest, err)
 	}
 
-	if err := h.service.Create(e, roleRequest, domain); err != nil {
-		return api.WebResponse(e, http.StatusInternalServerError, err)
+	subject, err := util.ExtractSubject(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+
+	if _, err := h.service.Create(subject, roleRequest, domain); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
 	}
 
 	return api.WebResponse(e, http.StatusCreated, api.RESOURCE_CREATED("Role created successfully"))
@@ -121,14 +143,14 @@ func (h *RoleHandler) Create(e echo.Context) error {
 
 // Read godoc
 // @Summary Get role
-// @Description Returns the details of a role by UUID within the specified domain.
+// @Description Returns the details of a role by UUID within the specified domain: its parent role, the permissions granted to it directly and the permissions it has in effect through its parent roles.
 // @ID role-read
 // @Tags Role Management
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param uuid path string true "Role UUID"
-// @Success 200 {object} models.Role
+// @Success 200 {object} responses.RoleResponse
 // @Failure 400 {object} api.Response
 // @Failure 404 {object} api.Response
 // @Router /api/role/{uuid} [get]
@@ -151,12 +173,16 @@ func (h *RoleHandler) Read(e echo.Context) error {
 		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to fetch role"))
 	}
 
-	return api.WebResponse(e, http.StatusOK, role)
+	response, err := h.roleResponse(role, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(e, http.StatusOK, response)
 }
 
 // Update godoc
 // @Summary Update role
-// @Description Modifies the details of a role by UUID within the specified domain.
+// @Description Modifies the details of a role by UUID within the specified domain. A rename carries the role's policies, user assignments and links to other roles over to the new name. The parent replaces the role the role inherits from, an empty parent removes it. Parents of System roles apply in every domain.
 // @ID role-update
 // @Tags Role Management
 // @Accept json
@@ -164,9 +190,11 @@ func (h *RoleHandler) Read(e echo.Context) error {
 // @Security ApiKeyAuth
 // @Param uuid path string true "Role UUID"
 // @Param params body requests.RoleRequest true "Role update data"
-// @Success 200 {object} models.Role
+// @Success 200 {object} responses.RoleResponse
 // @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
 // @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
 // @Router /api/role/{uuid} [put]
 func (h *RoleHandler) Update(e echo.Context) error {
 	roleRequest, err := h.validateRoleRequest(e)
@@ -192,19 +220,26 @@ func (h *RoleHandler) Update(e echo.Context) error {
 		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to fetch role"))
 	}
 
-	// Update role name
-	role.Name = roleRequest.Name
+	subject, err := util.ExtractSubject(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
 
-	if err := h.service.UpdateRole(role); err != nil {
-		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to update role"))
+	// Renaming rewrites the role's policies and assignments as well
+	if err := h.service.Update(subject, role, roleRequest, domain); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
 	}
 
-	return api.WebResponse(e, http.StatusOK, role)
+	response, err := h.roleResponse(role, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(e, http.StatusOK, response)
 }
 
 // Delete godoc
 // @Summary Delete role
-// @Description Removes a role by UUID from the specified domain.
+// @Description Removes a role by UUID from the specified domain together with its policies and user assignments. Roles inheriting from it no longer do.
 // @ID role-delete
 // @Tags Role Management
 // @Accept json
@@ -228,7 +263,7 @@ func (h *RoleHandler) Delete(e echo.Context) error {
 
 	var role models.Role
 	if err := h.service.DeleteRoleByUuidInDomain(&role, uuid, domain); err != nil {
-		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("Role not found"))
+		return api.WebResponse(e, policyStatusFor(err), err)
 	}
 
 	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("Role deleted successfully"))
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/responses"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// SessionHandler provides endpoints for listing and revoking login sessions,
+// both for the current user and for domain administrators managing the users of their domain.
+type SessionHandler struct {
+	BaseHandler
+	sessionService *services.SessionService
+	userService    *services.UserService
+	auditService   *services.AuditService
+}
+
+// NewSessionHandler initializes the SessionHandler with the provided server and its dependencies.
+func NewSessionHandler(server *server.Server) *SessionHandler {
+	return &SessionHandler{
+		BaseHandler: BaseHandler{
+			Server: server,
+		},
+		sessionService: services.NewSessionService(server),
+		userService:    services.NewUserService(server.DB),
+		auditService:   services.NewAuditService(),
+	}
+}
+
+// Type returns the string identifier for the SessionHandler.
+func (h *SessionHandler) Type() string {
+	return "Session"
+}
+
+// currentClaims extracts the claims of the access token from context
+func currentClaims(c echo.Context) (*services.JwtCustomClaims, bool) {
+	token, ok := c.Get("token").(*jwt.Token)
+	if !ok || token == nil {
+		return nil, false
+	}
+	claims, ok := token.Claims.(*services.JwtCustomClaims)
+	return claims, ok && claims != nil
+}
+
+// findUserInDomain resolves the user referenced by the 'user' query parameter within the domain
+func (h *SessionHandler) findUserInDomain(e echo.Context) (*models.User, error) {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return nil, api.FIELD_VALIDATION_ERROR("Missing domain information")
+	}
+	domain, _ := d.(*models.Domain)
+
+	userUUID := e.QueryParam("user")
+	if userUUID == "" {
+		return nil, api.FIELD_VALIDATION_ERROR("Missing user parameter")
+	}
+
+	var user models.User
+	h.userService.GetUserByUuidInDomain(&user, userUUID, domain)
+	if user.ID == 0 {
+		return nil, api.USER_NOT_FOUND()
+	}
+	return &user, nil
+}
+
+// findSessionInDomain loads a session by UUID, ensuring its owner belongs to the domain
+func (h *SessionHandler) findSessionInDomain(e echo.Context) (*services.Session, error) {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return nil, api.FIELD_VALIDATION_ERROR("Missing domain information")
+	}
+	domain, _ := d.(*models.Domain)
+
+	uuid, err := util.GetUUIDParam(e)
+	if err != nil {
+		return nil, api.FIELD_VALIDATION_ERROR("Missing or invalid UUID parameter")
+	}
+
+	session := new(services.Session)
+	if err := h.sessionService.Get(session, uuid); err != nil {
+		return nil, api.RESOURCE_NOT_FOUND("Session not found")
+	}
+
+	var owner models.User
+	if err := h.userService.GetUserByIdInDomain(&owner, session.UserID, domain); err != nil || owner.ID == 0 {
+		return nil, api.RESOURCE_NOT_FOUND("Session not found")
+	}
+	return session, nil
+}
+
+// statusFor maps the api response code to the HTTP status
+func statusFor(err error) int {
+	if res, ok := err.(api.Response); ok {
+		switch res.Code {
+		case api.CodeResourceNotFound, api.CodeUserNotFound:
+			return http.StatusNotFound
+		}
+	}
+	return http.StatusBadRequest
+}
+
+// ListMine godoc
+// @Summary List my sessions
+// @Description Returns the active sessions of the current user with device, IP and last activity.
+// @ID session-list-mine
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} responses.SessionResponse
+// @Failure 401 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /sessions [get]
+func (h *SessionHandler) ListMine(e echo.Context) error {
+	claims, ok := currentClaims(e)
+	if !ok {
+		return api.WebResponse(e, http.StatusUnauthorized, api.INVALID_TOKEN("Invalid token claims"))
+	}
+
+	sessions, err := h.sessionService.ListForUser(claims.UserID)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to fetch sessions"))
+	}
+
+	return api.WebResponse(e, http.StatusOK, responses.NewSessionResponse(sessions, claims.Family))
+}
+
+// RevokeMine godoc
+// @Summary Revoke one of my sessions
+// @Description Revokes a session of the current user, all tokens of that session stop working.
+// @ID session-revoke-mine
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "Session ID"
+// @Success 200 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /sessions/{uuid} [delete]
+func (h *SessionHandler) RevokeMine(e echo.Context) error {
+	claims, ok := currentClaims(e)
+	if !ok {
+		return api.WebResponse(e, http.StatusUnauthorized, api.INVALID_TOKEN("Invalid token claims"))
+	}
+
+	uuid, err := util.GetUUIDParam(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, err)
+	}
+
+	session := new(services.Session)
+	if err := h.sessionService.Get(session, uuid); err != nil || session.UserID != claims.UserID {
+		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("Session not found"))
+	}
+
+	if err := h.sessionService.Revoke(claims.UserID, session.ID); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to revoke session"))
+	}
+
+	log.Info().Str("event", "session_revoked").Uint64("user_id", claims.UserID).Str("session", session.ID).Msg("Session revoked")
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("Session revoked"))
+}
+
+// RevokeAllMine godoc
+// @Summary Revoke all my sessions
+// @Description Revokes every session of the current user, including the current one.
+// @ID session-revoke-all-mine
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /sessions [delete]
+func (h *SessionHandler) RevokeAllMine(e echo.Context) error {
+	claims, ok := currentClaims(e)
+	if !ok {
+		return api.WebResponse(e, http.StatusUnauthorized, api.INVALID_TOKEN("Invalid token claims"))
+	}
+
+	if err := h.sessionService.RevokeAll(claims.UserID); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to revoke sessions"))
+	}
+
+	log.Info().Str("event", "sessions_revoked").Uint64("user_id", claims.UserID).Msg("All sessions revoked")
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("All sessions revoked"))
+}
+
+// List godoc
+// @Summary List sessions of a user
+// @Description Returns the active sessions of a user of the specified domain.
+// @ID session-list
+// @Tags Session Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user query string true "User UUID"
+// @Success 200 {array} responses.SessionResponse
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /api/session [get]
+func (h *SessionHandler) List(e echo.Context) error {
+	user, err := h.findUserInDomain(e)
+	if err != nil {
+		return api.WebResponse(e, statusFor(err), err)
+	}
+
+	sessions, err := h.sessionService.ListForUser(user.ID)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to fetch sessions"))
+	}
+
+	claims, _ := currentClaims(e)
+	current := ""
+	if claims != nil {
+		current = claims.Family
+	}
+	return api.WebResponse(e, http.StatusOK, responses.NewSessionResponse(sessions, current))
+}
+
+// Read godoc
+// @Summary Get session
+// @Description Returns a session of a user of the specified domain.
+// @ID session-read
+// @Tags Session Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "Session ID"
+// @Success 200 {object} responses.SessionResponse
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /api/session/{uuid} [get]
+func (h *SessionHandler) Read(e echo.Context) error {
+	session, err := h.findSessionInDomain(e)
+	if err != nil {
+		return api.WebResponse(e, statusFor(err), err)
+	}
+
+	claims, _ := currentClaims(e)
+	current := ""
+	if claims != nil {
+		current = claims.Family
+	}
+	res := *responses.NewSessionResponse([]*services.Session{session}, current)
+	return api.WebResponse(e, http.StatusOK, res[0])
+}
+
+// Delete godoc
+// @Summary Revoke session
+// @Description Revokes a session of a user of the specified domain.
+// @ID session-delete
+// @Tags Session Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "Session ID"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /api/session/{uuid} [delete]
+func (h *SessionHandler) Delete(e echo.Context) error {
+	session, err := h.findSessionInDomain(e)
+	if err != nil {
+		return api.WebResponse(e, statusFor(err), err)
+	}
+
+	if err := h.sessionService.Revoke(session.UserID, session.ID); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to revoke session"))
+	}
+
+	h.recordRevocation(e, "session_revoked_by_admin", session.UserID, session.ID)
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("Session revoked"))
+}
+
+// DeleteAll godoc
+// @Summary Revoke all sessions of a user
+// @Description Revokes every session of a user of the specified domain.
+// @ID session-delete-all
+// @Tags Session Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user query string true "User UUID"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Router /api/session [delete]
+func (h *SessionHandler) DeleteAll(e echo.Context) error {
+	user, err := h.findUserInDomain(e)
+	if err != nil {
+		return api.WebResponse(e, statusFor(err), err)
+	}
+
+	if err := h.sessionService.RevokeAll(user.ID); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to revoke sessions"))
+	}
+
+	h.recordRevocation(e, "sessions_revoked_by_admin", user.ID, "")
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("All sessions revoked"))
+}
+
+// recordRevocation audits a revocation performed on behalf of another user
+func (h *SessionHandler) recordRevocation(e echo.Context, event string, userID uint64, sessionID string) {
+	details := map[string]interface{}{"session": sessionID}
+	if admin, ok := e.Get("user").(*models.User); ok && admin != nil {
+		details["revoked_by"] = admin.UUID.String()
+	}
+	h.auditService.Record(services.AuditEvent{
+		Event:   event,
+		UserID:  userID,
+		Details: details,
+	})
+}
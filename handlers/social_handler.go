@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"goweb/api"
 	"goweb/models"
+	"goweb/responses"
 	"goweb/server"
 	"goweb/services"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -19,6 +21,7 @@ type SocialHandler struct {
 	server        *server.Server
 	socialService *services.SocialService
 	tokenService  *services.TokenService
+	mfaService    *services.MFAService
 }
 
 // NewSocialHandler initializes the SocialHandler with the provided server and its dependencies
@@ -27,40 +30,191 @@ func NewSocialHandler(server *server.Server) *SocialHandler {
 		server:        server,
 		socialService: services.NewSocialService(server),
 		tokenService:  services.NewTokenService(server),
+		mfaService:    services.NewMFAService(server),
 	}
 }
 
-// Helper to generate token pair and redirect to frontend
-func (h *SocialHandler) respondWithTokenPair(c echo.Context, user *models.User) error {
-	accessToken, refreshToken, _, err := h.tokenService.GenerateTokenPair(user)
+// oauthBindingCookie ties a pending social login to the browser that started it
+const oauthBindingCookie = "oauth_binding"
+
+// Helper to generate token pair and redirect to frontend, redirectTo is handed on so the frontend can return to where the login started
+func (h *SocialHandler) respondWithTokenPair(c echo.Context, user *models.User, redirectTo string) error {
+	frontendURL := h.frontendURL()
+
+	// Users with two-factor authentication complete the login at /login/mfa
+	if user.MFAEnabled {
+		mfaToken, _, err := h.mfaService.CreatePendingLogin(user)
+		if err != nil {
+			log.Error().Str("event", "mfa_challenge_failed").Err(err).Uint64("user_id", user.ID).Msg("Failed to start two-factor login")
+			return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to start two-factor login"))
+		}
+		return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth/callback?mfa_token=%s%s", frontendURL, mfaToken, redirectParam(redirectTo)))
+	}
+
+	accessToken, refreshToken, _, err := h.tokenService.GenerateTokenPair(user, services.NewClientInfo(c), false)
 	if err != nil {
 		log.Error().Str("event", "token_generation_failed").Err(err).Uint64("user_id", uint64(user.ID)).Msg("Failed to generate authentication tokens")
 		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Failed to generate authentication tokens"))
 	}
 
 	// Redirect to frontend with tokens as URL parameters
-	frontendURL := h.server.Config.HTTP.FrontendURL
-	if frontendURL == "" {
-		frontendURL = "http://localhost:3000" // fallback for development
-	}
-	redirectURL := fmt.Sprintf("%s/auth/callback?access_token=%s&refresh_token=%s", frontendURL, accessToken, refreshToken)
+	redirectURL := fmt.Sprintf("%s/auth/callback?access_token=%s&refresh_token=%s%s", frontendURL, accessToken, refreshToken, redirectParam(redirectTo))
 	return c.Redirect(http.StatusTemporaryRedirect, redirectURL)
 }
 
+func redirectParam(redirectTo string) string {
+	if redirectTo == "" {
+		return ""
+	}
+	return "&redirect_to=" + url.QueryEscape(redirectTo)
+}
+
+// frontendURL is the base URL of the frontend the callbacks redirect to
+func (h *SocialHandler) frontendURL() string {
+	if h.server.Config.HTTP.FrontendURL == "" {
+		return "http://localhost:3000" // fallback for development
+	}
+	return h.server.Config.HTTP.FrontendURL
+}
+
+// completeLogin answers a successful callback, linked accounts return to the frontend page the link was started from
+func (h *SocialHandler) completeLogin(c echo.Context, user *models.User, pending *services.OAuthState) error {
+	if pending.LinkUserID == 0 {
+		return h.respondWithTokenPair(c, user, pending.RedirectTo)
+	}
+
+	log.Info().Str("event", "identity_linked").Uint64("user_id", user.ID).Str("provider", pending.Provider).Msg("Account linked")
+	return c.Redirect(http.StatusTemporaryRedirect, h.linkRedirect(pending, "linked", pending.Provider))
+}
+
+// respondWithLoginError redirects to the frontend for errors the user can act on, like an email already in use,
+// other errors are answered with fallback
+func (h *SocialHandler) respondWithLoginError(c echo.Context, pending *services.OAuthState, err error, fallback string) error {
+	res, ok := err.(api.Response)
+	if !ok || (res.Code != api.CodeUserExists && res.Code != api.CodeResourceExists) {
+		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR(fallback))
+	}
+	if pending.LinkUserID != 0 {
+		return c.Redirect(http.StatusTemporaryRedirect, h.linkRedirect(pending, "link_error", res.Msg))
+	}
+	return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth/callback?error=%s%s", h.frontendURL(), url.QueryEscape(res.Msg), redirectParam(pending.RedirectTo)))
+}
+
+// linkRedirect is the frontend page a link returns to, with the outcome as query parameter
+func (h *SocialHandler) linkRedirect(pending *services.OAuthState, param, value string) string {
+	target, _ := url.Parse(pending.RedirectTo)
+	if target == nil || pending.RedirectTo == "" {
+		target = &url.URL{Path: "/"}
+	}
+	query := target.Query()
+	query.Set(param, value)
+	target.RawQuery = query.Encode()
+	return h.frontendURL() + target.String()
+}
+
+// authorizationURL starts a login with the provider and returns the URL to send the browser to,
+// a linkUserID links the provider account to that user instead of logging in.
+// The provider is resolved first, so no state is stored for a login that cannot start.
+func (h *SocialHandler) authorizationURL(c echo.Context, provider string, linkUserID uint64) (string, error) {
+	var config *oauth2.Config
+	var options []oauth2.AuthCodeOption
+	oidc := false
+	switch provider {
+	case "google":
+		config = h.socialService.GetGoogleOAuthConfig()
+		options = append(options, oauth2.AccessTypeOffline)
+	case "github":
+		config = h.socialService.GetGitHubOAuthConfig()
+	default:
+		oidcProvider, ok := h.socialService.GetOIDCProvider(provider)
+		if !ok {
+			return "", api.RESOURCE_NOT_FOUND("Unknown login provider")
+		}
+		var err error
+		if config, err = oidcProvider.OAuthConfig(c.Request().Context()); err != nil {
+			log.Error().Str("event", "oidc_discovery_failed").Str("provider", provider).Err(err).Msg("OpenID Connect discovery failed")
+			return "", api.INTERNAL_SERVICE_ERROR("Login provider unavailable")
+		}
+		oidc = true
+	}
+
+	state, pending, err := h.beginOAuthLogin(c, provider, linkUserID)
+	if err != nil {
+		return "", err
+	}
+	options = append(options, oauth2.S256ChallengeOption(pending.Verifier))
+	if oidc {
+		options = append(options, oauth2.SetAuthURLParam("nonce", pending.Nonce))
+	}
+	return config.AuthCodeURL(state, options...), nil
+}
+
+// beginOAuthLogin stores a new state with PKCE verifier, sets the binding cookie and returns the state
+func (h *SocialHandler) beginOAuthLogin(c echo.Context, provider string, linkUserID uint64) (string, *services.OAuthState, error) {
+	state, pending, err := h.socialService.CreateOAuthState(provider, c.QueryParam("redirect_to"), linkUserID)
+	if err != nil {
+		return "", nil, err
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     oauthBindingCookie,
+		Value:    pending.Binding,
+		Path:     "/auth",
+		MaxAge:   services.SocialStateMinutes * 60,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		// Lax still sends the cookie on the top-level redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+	})
+	return state, pending, nil
+}
+
+// finishOAuthLogin verifies the state of a callback against the binding cookie and clears the cookie
+func (h *SocialHandler) finishOAuthLogin(c echo.Context, provider string) (*services.OAuthState, error) {
+	binding := ""
+	if cookie, err := c.Cookie(oauthBindingCookie); err == nil {
+		binding = cookie.Value
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     oauthBindingCookie,
+		Path:     "/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return h.socialService.ConsumeOAuthState(provider, c.QueryParam("state"), binding)
+}
+
+// oauthBeginStatusFor maps the api response code of a login start to the HTTP status
+func oauthBeginStatusFor(err error) int {
+	if res, ok := err.(api.Response); ok {
+		switch res.Code {
+		case api.CodeFieldValidationError:
+			return http.StatusBadRequest
+		case api.CodeResourceNotFound:
+			return http.StatusNotFound
+		}
+	}
+	return http.StatusInternalServerError
+}
+
 // GoogleLogin godoc
 // @Summary Initiate Google OAuth login
-// @Description Redirects user to Google OAuth for authentication
+// @Description Redirects user to Google OAuth for authentication. Every login gets its own state and PKCE verifier, valid for 10 minutes and bound to the browser by a cookie.
 // @ID google-login
 // @Tags Social Login
 // @Accept json
 // @Produce json
-// @Success 200 {object} api.Response
+// @Param redirect_to query string false "Relative frontend path handed back to /auth/callback after the login"
+// @Success 307 {string} string "Redirect to the provider"
 // @Failure 400 {object} api.Response
 // @Failure 500 {object} api.Response
 // @Router /auth/google [get]
 func (h *SocialHandler) GoogleLogin(c echo.Context) error {
-	config := h.socialService.GetGoogleOAuthConfig()
-	url := config.AuthCodeURL("state", oauth2.AccessTypeOffline)
+	url, err := h.authorizationURL(c, "google", 0)
+	if err != nil {
+		return api.WebResponse(c, oauthBeginStatusFor(err), err)
+	}
 	return c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
@@ -72,8 +226,8 @@ func (h *SocialHandler) GoogleLogin(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param code query string true "Authorization code from Google"
-// @Param state query string false "State parameter"
-// @Success 200 {object} responses.LoginResponse
+// @Param state query string true "State parameter issued by /auth/google"
+// @Success 307 {string} string "Redirect to /auth/callback of the frontend with the tokens"
 // @Failure 400 {object} api.Response
 // @Failure 401 {object} api.Response
 // @Failure 500 {object} api.Response
@@ -81,40 +235,46 @@ func (h *SocialHandler) GoogleLogin(c echo.Context) error {
 func (h *SocialHandler) GoogleCallback(c echo.Context) error {
 	start := time.Now()
 	code := c.QueryParam("code")
-	state := c.QueryParam("state")
+
+	// The state is checked first, so a forged callback is rejected before anything else is done with it
+	pending, err := h.finishOAuthLogin(c, "google")
+	if err != nil {
+		log.Info().Str("event", "google_callback_failed").Str("error", "invalid_state").Str("ip", c.RealIP()).Msg("Google callback failed: invalid state")
+		return api.WebResponse(c, http.StatusUnauthorized, err)
+	}
 
 	if code == "" {
 		log.Info().Str("event", "google_callback_failed").Str("error", "missing_code").Msg("Google callback failed: missing authorization code")
 		return api.WebResponse(c, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing authorization code"))
 	}
 
-	// TODO: Validate state parameter for security
-	_ = state
-
-	user, err := h.socialService.HandleGoogleLogin(code)
+	user, err := h.socialService.HandleGoogleLogin(code, pending)
 	if err != nil {
 		log.Error().Str("event", "google_login_failed").Err(err).Msg("Google login failed")
-		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("Google login failed"))
+		return h.respondWithLoginError(c, pending, err, "Google login failed")
 	}
 
 	log.Info().Str("event", "google_login_success").Uint64("user_id", uint64(user.ID)).Str("email", user.Email).Int64("duration_ms", time.Since(start).Milliseconds()).Msg("Google login successful")
-	return h.respondWithTokenPair(c, user)
+	return h.completeLogin(c, user, pending)
 }
 
 // GitHubLogin godoc
 // @Summary Initiate GitHub OAuth login
-// @Description Redirects user to GitHub OAuth for authentication
+// @Description Redirects user to GitHub OAuth for authentication. Every login gets its own state and PKCE verifier, valid for 10 minutes and bound to the browser by a cookie.
 // @ID github-login
 // @Tags Social Login
 // @Accept json
 // @Produce json
-// @Success 200 {object} api.Response
+// @Param redirect_to query string false "Relative frontend path handed back to /auth/callback after the login"
+// @Success 307 {string} string "Redirect to the provider"
 // @Failure 400 {object} api.Response
 // @Failure 500 {object} api.Response
 // @Router /auth/github [get]
 func (h *SocialHandler) GitHubLogin(c echo.Context) error {
-	config := h.socialService.GetGitHubOAuthConfig()
-	url := config.AuthCodeURL("state")
+	url, err := h.authorizationURL(c, "github", 0)
+	if err != nil {
+		return api.WebResponse(c, oauthBeginStatusFor(err), err)
+	}
 	return c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
@@ -126,8 +286,8 @@ func (h *SocialHandler) GitHubLogin(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param code query string true "Authorization code from GitHub"
-// @Param state query string false "State parameter"
-// @Success 200 {object} responses.LoginResponse
+// @Param state query string true "State parameter issued by /auth/github"
+// @Success 307 {string} string "Redirect to /auth/callback of the frontend with the tokens"
 // @Failure 400 {object} api.Response
 // @Failure 401 {object} api.Response
 // @Failure 500 {object} api.Response
@@ -135,22 +295,121 @@ func (h *SocialHandler) GitHubLogin(c echo.Context) error {
 func (h *SocialHandler) GitHubCallback(c echo.Context) error {
 	start := time.Now()
 	code := c.QueryParam("code")
-	state := c.QueryParam("state")
+
+	// The state is checked first, so a forged callback is rejected before anything else is done with it
+	pending, err := h.finishOAuthLogin(c, "github")
+	if err != nil {
+		log.Info().Str("event", "github_callback_failed").Str("error", "invalid_state").Str("ip", c.RealIP()).Msg("GitHub callback failed: invalid state")
+		return api.WebResponse(c, http.StatusUnauthorized, err)
+	}
 
 	if code == "" {
 		log.Info().Str("event", "github_callback_failed").Str("error", "missing_code").Msg("GitHub callback failed: missing authorization code")
 		return api.WebResponse(c, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing authorization code"))
 	}
 
-	// TODO: Validate state parameter for security
-	_ = state
-
-	user, err := h.socialService.HandleGitHubLogin(code)
+	user, err := h.socialService.HandleGitHubLogin(code, pending)
 	if err != nil {
 		log.Error().Str("event", "github_login_failed").Err(err).Msg("GitHub login failed")
-		return api.WebResponse(c, http.StatusInternalServerError, api.INTERNAL_SERVICE_ERROR("GitHub login failed"))
+		return h.respondWithLoginError(c, pending, err, "GitHub login failed")
 	}
 
 	log.Info().Str("event", "github_login_success").Uint64("user_id", uint64(user.ID)).Str("email", user.Email).Int64("duration_ms", time.Since(start).Milliseconds()).Msg("GitHub login successful")
-	return h.respondWithTokenPair(c, user)
+	return h.completeLogin(c, user, pending)
+}
+
+// OIDCLogin godoc
+// @Summary Initiate OpenID Connect login
+// @Description Redirects user to a configured OpenID Connect provider (e.g. Keycloak, Azure AD) for authentication. Like Google and GitHub logins, every login gets its own state, PKCE verifier and nonce, valid for 10 minutes and bound to the browser by a cookie.
+// @ID oidc-login
+// @Tags Social Login
+// @Accept json
+// @Produce json
+// @Param provider path string true "Name of the provider in OIDC_PROVIDERS"
+// @Param redirect_to query string false "Relative frontend path handed back to /auth/callback after the login"
+// @Success 307 {string} string "Redirect to the provider"
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /auth/{provider} [get]
+func (h *SocialHandler) OIDCLogin(c echo.Context) error {
+	url, err := h.authorizationURL(c, c.Param("provider"), 0)
+	if err != nil {
+		return api.WebResponse(c, oauthBeginStatusFor(err), err)
+	}
+	return c.Redirect(http.StatusTemporaryRedirect, url)
+}
+
+// OIDCCallback godoc
+// @Summary Handle OpenID Connect callback
+// @Description Processes the callback of an OpenID Connect provider, verifies the ID token against the provider's JWKS and authenticates the user
+// @ID oidc-callback
+// @Tags Social Login
+// @Accept json
+// @Produce json
+// @Param provider path string true "Name of the provider in OIDC_PROVIDERS"
+// @Param code query string true "Authorization code from the provider"
+// @Param state query string true "State parameter issued by /auth/{provider}"
+// @Success 307 {string} string "Redirect to /auth/callback of the frontend with the tokens"
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /auth/{provider}/callback [get]
+func (h *SocialHandler) OIDCCallback(c echo.Context) error {
+	start := time.Now()
+	provider, ok := h.socialService.GetOIDCProvider(c.Param("provider"))
+	if !ok {
+		return api.WebResponse(c, http.StatusNotFound, api.RESOURCE_NOT_FOUND("Unknown login provider"))
+	}
+	code := c.QueryParam("code")
+
+	pending, err := h.finishOAuthLogin(c, provider.Name())
+	if err != nil {
+		log.Info().Str("event", "oidc_callback_failed").Str("provider", provider.Name()).Str("error", "invalid_state").Str("ip", c.RealIP()).Msg("OpenID Connect callback failed: invalid state")
+		return api.WebResponse(c, http.StatusUnauthorized, err)
+	}
+
+	if code == "" {
+		log.Info().Str("event", "oidc_callback_failed").Str("provider", provider.Name()).Str("error", "missing_code").Msg("OpenID Connect callback failed: missing authorization code")
+		return api.WebResponse(c, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing authorization code"))
+	}
+
+	user, err := h.socialService.HandleOIDCLogin(provider, code, pending)
+	if err != nil {
+		log.Error().Str("event", "oidc_login_failed").Str("provider", provider.Name()).Err(err).Msg("OpenID Connect login failed")
+		return h.respondWithLoginError(c, pending, err, "Login failed")
+	}
+
+	log.Info().Str("event", "oidc_login_success").Str("provider", provider.Name()).Uint64("user_id", user.ID).Str("email", user.Email).Int64("duration_ms", time.Since(start).Milliseconds()).Msg("OpenID Connect login successful")
+	return h.completeLogin(c, user, pending)
+}
+
+// Link godoc
+// @Summary Link a social account
+// @Description Starts a login with the provider (google, github or a name in OIDC_PROVIDERS) that links the provider account to the current user instead of logging in. Send the browser to the returned URL; after the provider the browser returns to `redirect_to` (default `/`) with `linked=<provider>` or `link_error=<message>`.
+// @ID identity-link
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "Provider to link"
+// @Param redirect_to query string false "Relative frontend path to return to after the link"
+// @Success 200 {object} responses.IdentityLinkResponse
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /identities/{provider} [post]
+func (h *SocialHandler) Link(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+
+	url, err := h.authorizationURL(c, c.Param("provider"), user.ID)
+	if err != nil {
+		return api.WebResponse(c, oauthBeginStatusFor(err), err)
+	}
+	return api.WebResponse(c, http.StatusOK, responses.NewIdentityLinkResponse(url))
 }
@@ -15,7 +15,11 @@ import (
 // UserHandler provides endpoints for managing users within a domain, including CRUD operations.
 type UserHandler struct {
 	BaseHandler
-	UserService *services.UserService
+	UserService   *services.UserService
+	LoginThrottle *services.LoginThrottleService
+	RoleService   *services.RoleService
+	RoleGrants    *services.RoleGrantService
+	auditService  *services.AuditService
 }
 
 // NewUserHandler initializes the UserHandler with the provided server and its dependencies.
@@ -27,7 +31,11 @@ func NewUserHandler(server *server.Server) *UserHandler {
 		BaseHandler: BaseHandler{
 			Server: server,
 		},
-		UserService: userService,
+		UserService:   userService,
+		LoginThrottle: services.NewLoginThrottleService(server),
+		RoleService:   services.NewRoleService(server),
+		RoleGrants:    services.NewRoleGrantService(server),
+		auditService:  services.NewAuditService(),
 	}
 }
 
@@ -187,3 +195,211 @@ func (u *UserHandler) Delete(e echo.Context) error {
 	}
 	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("User deleted"))
 }
+
+// Unlock godoc
+// @Summary Unlock user login
+// @Description Lifts the login lockout and backoff of a user of the specified domain before they expire.
+// @ID user-unlock
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/user/{uuid}/lockout [delete]
+func (u *UserHandler) Unlock(e echo.Context) error {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR())
+	}
+	domain, _ := d.(*models.Domain)
+	user, err := findUserByUUID(e, u.UserService, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("User not found"))
+	}
+
+	if err := u.LoginThrottle.Unlock(user.Email); err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+
+	details := map[string]interface{}{"domain": domain.UUID.String()}
+	if subject, err := util.ExtractSubject(e); err == nil {
+		details["unlocked_by"] = subject
+	}
+	u.auditService.Record(services.AuditEvent{
+		Event:   "login_unlocked_by_admin",
+		UserID:  user.ID,
+		Details: details,
+	})
+	return api.WebResponse(e, http.StatusOK, api.STATUS_OK("Login unlocked"))
+}
+
+// ListRoles godoc
+// @Summary List user roles
+// @Description Returns the roles a user of the specified domain is assigned in it.
+// @ID user-role-list
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Success 200 {array} string
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/user/{uuid}/role [get]
+func (u *UserHandler) ListRoles(e echo.Context) error {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR())
+	}
+	domain, _ := d.(*models.Domain)
+	user, err := findUserByUUID(e, u.UserService, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("User not found"))
+	}
+
+	roles, err := u.RoleService.GetRolesOfUser(user, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(e, http.StatusOK, roles)
+}
+
+// ListRoleGrants godoc
+// @Summary List time-bound role grants of user
+// @Description Returns the time-bound role grants of a user of the specified domain, newest first. Ended grants are included with the time and reason they ended.
+// @ID user-role-grant-list
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Success 200 {array} models.RoleGrant
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/user/{uuid}/role/grants [get]
+func (u *UserHandler) ListRoleGrants(e echo.Context) error {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR())
+	}
+	domain, _ := d.(*models.Domain)
+	user, err := findUserByUUID(e, u.UserService, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("User not found"))
+	}
+
+	grants, err := u.RoleGrants.List(user, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusInternalServerError, err)
+	}
+	return api.WebResponse(e, http.StatusOK, grants)
+}
+
+// AssignRole godoc
+// @Summary Assign role to user
+// @Description Assigns a role of the specified domain to a user of it. Only roles whose permissions the caller holds can be assigned.
+// @Description With valid_until the role is granted for a limited time, from valid_from or right away. It is in effect only within that window and removed once it has expired.
+// @ID user-role-assign
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Param params body requests.RoleAssignmentRequest true "Role to assign"
+// @Success 201 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/user/{uuid}/role [post]
+func (u *UserHandler) AssignRole(e echo.Context) error {
+	request, err := util.BindAndValidate[requests.RoleAssignmentRequest](e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Invalid request format"))
+	}
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR())
+	}
+	domain, _ := d.(*models.Domain)
+	subject, err := util.ExtractSubject(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	user, err := findUserByUUID(e, u.UserService, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("User not found"))
+	}
+
+	details := map[string]interface{}{"domain": domain.UUID.String(), "role": request.Role, "assigned_by": subject}
+	if request.ValidUntil == nil {
+		err = u.RoleService.AssignToUser(subject, user, request.Role, domain)
+	} else {
+		var grant *models.RoleGrant
+		if grant, err = u.RoleGrants.Grant(subject, user, request, domain); err == nil {
+			details["valid_from"] = grant.ValidFrom
+			details["valid_until"] = grant.ValidUntil
+		}
+	}
+	if err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
+
+	u.auditService.Record(services.AuditEvent{
+		Event:   "role_assigned",
+		UserID:  user.ID,
+		Details: details,
+	})
+	return api.WebResponse(e, http.StatusCreated, api.RESOURCE_CREATED("Role assigned"))
+}
+
+// UnassignRole godoc
+// @Summary Unassign role from user
+// @Description Removes a role of the specified domain from a user of it. Only roles whose permissions the caller holds can be removed.
+// @ID user-role-unassign
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Param role path string true "Role name"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 403 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /api/user/{uuid}/role/{role} [delete]
+func (u *UserHandler) UnassignRole(e echo.Context) error {
+	d, err := util.ExtractDomain(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR())
+	}
+	domain, _ := d.(*models.Domain)
+	subject, err := util.ExtractSubject(e)
+	if err != nil {
+		return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+	}
+	user, err := findUserByUUID(e, u.UserService, domain)
+	if err != nil {
+		return api.WebResponse(e, http.StatusNotFound, api.RESOURCE_NOT_FOUND("User not found"))
+	}
+
+	role := e.Param("role")
+	if err := u.RoleService.UnassignFromUser(subject, user, role, domain); err != nil {
+		return api.WebResponse(e, policyStatusFor(err), err)
+	}
+
+	u.auditService.Record(services.AuditEvent{
+		Event:   "role_unassigned",
+		UserID:  user.ID,
+		Details: map[string]interface{}{"domain": domain.UUID.String(), "role": role, "unassigned_by": subject},
+	})
+	return api.WebResponse(e, http.StatusOK, api.RESOURCE_DELETED("Role unassigned"))
+}
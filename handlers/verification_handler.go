@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"goweb/api"
+	"goweb/requests"
+	"goweb/server"
+	"goweb/services"
+	"goweb/util"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// VerificationHandler provides endpoints for users to verify their email address.
+type VerificationHandler struct {
+	server              *server.Server
+	verificationService *services.VerificationService
+}
+
+// NewVerificationHandler initializes the VerificationHandler with the provided server and its dependencies.
+func NewVerificationHandler(server *server.Server) *VerificationHandler {
+	return &VerificationHandler{
+		server:              server,
+		verificationService: services.NewVerificationService(server),
+	}
+}
+
+// Verify godoc
+// @Summary Verify email
+// @Description Marks the email of the user as verified with the token of the link mailed on registration. Links are valid for 24 hours and only for the address they were sent to.
+// @ID email-verify
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Param params body requests.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /verify-email [post]
+func (h *VerificationHandler) Verify(c echo.Context) error {
+	verifyRequest, err := util.BindAndValidate[requests.VerifyEmailRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	user, err := h.verificationService.Verify(verifyRequest.Token)
+	if err != nil {
+		log.Info().Str("event", "email_verification_failed").Str("ip", c.RealIP()).Str("error", err.Error()).Msg("Email verification failed")
+		if res, ok := err.(api.Response); ok && res.Code == api.CodeInternalServiceError {
+			return api.WebResponse(c, http.StatusInternalServerError, err)
+		}
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	log.Info().Str("event", "email_verified").Uint64("user_id", user.ID).Str("email", user.Email).Msg("Email verified")
+	return api.WebResponse(c, http.StatusOK, api.STATUS_OK("Email verified"))
+}
+
+// Resend godoc
+// @Summary Resend verification email
+// @Description Mails a new verification link. Limited to one email per address every 60 seconds and 10 per IP and hour. The response is the same for unknown and already verified addresses.
+// @ID email-verify-resend
+// @Tags Account Actions
+// @Accept json
+// @Produce json
+// @Param params body requests.ResendVerificationRequest true "Account email"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /verify-email/resend [post]
+func (h *VerificationHandler) Resend(c echo.Context) error {
+	resendRequest, err := util.BindAndValidate[requests.ResendVerificationRequest](c)
+	if err != nil {
+		return api.WebResponse(c, http.StatusBadRequest, err)
+	}
+
+	if err := h.verificationService.Resend(resendRequest.Email, c.RealIP()); err != nil {
+		if res, ok := err.(api.Response); ok && res.Code == api.CodeTooManyRequests {
+			log.Info().Str("event", "verification_resend_limited").Str("email", resendRequest.Email).Str("ip", c.RealIP()).Msg("Verification email rate limited")
+			return api.WebResponse(c, http.StatusTooManyRequests, err)
+		}
+		return api.WebResponse(c, http.StatusInternalServerError, err)
+	}
+
+	return api.WebResponse(c, http.StatusOK, api.STATUS_OK("If the email belongs to an unverified account, a verification link was sent"))
+}
@@ -1,8 +1,6 @@
 package interceptor
 
 import (
-	"context"
-	"fmt"
 	"goweb/api"
 	"goweb/models"
 	"goweb/server"
@@ -16,16 +14,27 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// Headers machine clients authenticate with
+const (
+	APIKeyHeader    = "X-API-Key"
+	APISecretHeader = "X-API-Secret"
+)
+
 // Middleware for additional steps:
-// 1. Check the token info exists in Redis
+// 1. Check the token belongs to an active session in Redis
 // 2. Check the user exists in DB
 // 3. Add the user data to Echo Context
-// 4. Prolong the Redis TTL of the current token pair
+// 4. Reject unverified users in domains requiring a verified email
+// 5. Record the session activity & prolong its Redis TTL
 func JwtClaimsAuthorizationMw(server *server.Server) echo.MiddlewareFunc {
 	tokenService := services.NewTokenService(server)
 	domainService := services.NewDomainService(server.DB)
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if APIKeyAuthenticated(c) {
+				return next(c)
+			}
+
 			tokenVal := c.Get("token")
 			token, ok := tokenVal.(*jwt.Token)
 			if !ok || token == nil {
@@ -45,6 +54,7 @@ func JwtClaimsAuthorizationMw(server *server.Server) echo.MiddlewareFunc {
 			}
 
 			c.Set("user", user)
+			c.Set("subject", user.UUID.String())
 
 			domain := new(models.Domain)
 			err = domainService.GetDomainByUUID(domain, domainuuid)
@@ -54,34 +64,113 @@ func JwtClaimsAuthorizationMw(server *server.Server) echo.MiddlewareFunc {
 
 			c.Set("domain", domain)
 
-			// Asynchronously update Redis TTL for the token in a goroutine
-			go func(userID uint64) {
-				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-				defer cancel()
-				key := fmt.Sprintf("token-%d", userID)
-				if err := server.Redis.Expire(ctx, key, time.Minute*services.AutoLogoffMinutes).Err(); err != nil {
-					log.Error().Str("event", "redis_ttl_update_failed").Str("key", key).Err(err).Msg("Failed to update Redis TTL")
+			if domain.RequireVerifiedEmail && !user.IsVerified {
+				return api.WebResponse(c, http.StatusForbidden, api.EMAIL_NOT_VERIFIED("Domain requires a verified email, follow the link sent to your email or request a new one at /verify-email/resend"))
+			}
+
+			// Asynchronously record the session activity in a goroutine
+			go func(claims *services.JwtCustomClaims, ip string) {
+				if err := tokenService.TouchSession(claims, ip); err != nil {
+					log.Error().Str("event", "session_touch_failed").Str("session", claims.Family).Err(err).Msg("Failed to update session activity")
+				}
+			}(claims, c.RealIP())
+
+			return next(c)
+		}
+	}
+}
+
+// APIKeyAuthenticationMw authenticates machine clients by the X-API-Key & X-API-Secret headers.
+// Requests without an API key fall through to the JWT middlewares. Authenticated requests act as
+// the key's own Casbin subject within the key's domain, the key owner is set as user.
+func APIKeyAuthenticationMw(server *server.Server) echo.MiddlewareFunc {
+	apiKeyService := services.NewAPIKeyService(server)
+	domainService := services.NewDomainService(server.DB)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(APIKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+
+			apiKey, err := apiKeyService.Authenticate(key, c.Request().Header.Get(APISecretHeader))
+			if err != nil {
+				log.Warn().Str("event", "api_key_auth_failed").Str("api_key", key).Str("ip", c.RealIP()).Msg("API key authentication failed")
+				return api.WebResponse(c, http.StatusUnauthorized, err)
+			}
+
+			// Keys are bound to their domain, the domain header is optional but must match
+			domainuuid := c.Request().Header.Get("domain")
+			if domainuuid != "" && domainuuid != apiKey.Domain.String() {
+				return api.WebResponse(c, http.StatusForbidden, api.CASBIN_UNAUTHORIZED("API key is not valid for this domain"))
+			}
+			c.Request().Header.Set("domain", apiKey.Domain.String())
+
+			domain := new(models.Domain)
+			err = domainService.GetDomainByUUID(domain, apiKey.Domain.String())
+			if err != nil || domain.ID == 0 {
+				return api.WebResponse(c, http.StatusBadRequest, api.FIELD_VALIDATION_ERROR("Missing or incorrect domain"))
+			}
+
+			c.Set("apikey", apiKey)
+			c.Set("user", &apiKey.User)
+			c.Set("subject", apiKey.UUID.String())
+			c.Set("domain", domain)
+
+			go func(apiKey *models.APIKey) {
+				if err := apiKeyService.Touch(apiKey); err != nil {
+					log.Error().Str("event", "api_key_touch_failed").Str("api_key", apiKey.UUID.String()).Err(err).Msg("Failed to update API key usage")
 				}
-			}(claims.UserID)
+			}(apiKey)
 
 			return next(c)
 		}
 	}
 }
 
+// APIKeyAuthenticated reports whether the request was authenticated by an API key,
+// used to skip the JWT middlewares
+func APIKeyAuthenticated(c echo.Context) bool {
+	return c.Get("apikey") != nil
+}
+
+// MFAEnforcementMw rejects tokens from single factor logins in domains requiring two-factor authentication.
+// API keys are exempt, they are managed by the domain's administrators.
+func MFAEnforcementMw(server *server.Server) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			domain, ok := c.Get("domain").(*models.Domain)
+			if !ok || domain == nil || !domain.RequireMFA || APIKeyAuthenticated(c) {
+				return next(c)
+			}
+
+			token, ok := c.Get("token").(*jwt.Token)
+			if ok && token != nil {
+				if claims, ok := token.Claims.(*services.JwtCustomClaims); ok && claims.MFA {
+					return next(c)
+				}
+			}
+
+			msg := "Domain requires two-factor authentication, sign in with a second factor"
+			if user, ok := c.Get("user").(*models.User); ok && user != nil && !user.MFAEnabled {
+				msg = "Domain requires two-factor authentication, enroll at /mfa/totp and sign in again"
+			}
+			return api.WebResponse(c, http.StatusForbidden, api.MFA_REQUIRED(msg))
+		}
+	}
+}
+
 // Domain Authorization: Check if user belongs to domain
 func CasbinAuthorization(server *server.Server) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Get domain from header identified by UUID
 			domain := c.Request().Header.Get("domain")
-			userVal := c.Get("user")
-			userModel, ok := userVal.(*models.User)
-			if !ok || userModel == nil {
+			// Subject is the user UUID, or the API key UUID for machine clients
+			user, err := util.ExtractSubject(c)
+			if err != nil {
 				return api.WebResponse(c, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
 			}
-			// Get user name as UUID
-			user := userModel.UUID.String()
 
 			// Check, user though maybe associated with a domain in DB
 			// Does he has casbin domain assigned to him or not
@@ -101,12 +190,10 @@ func CasbinAuthorization(server *server.Server) echo.MiddlewareFunc {
 func ResourceAuthorization(server *server.Server, resource string, action string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(e echo.Context) error {
-			userVal := e.Get("user")
-			userModel, ok := userVal.(*models.User)
-			if !ok || userModel == nil {
+			user, err := util.ExtractSubject(e)
+			if err != nil {
 				return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
 			}
-			user := userModel.UUID.String()
 			domain := e.Request().Header.Get("domain")
 
 			// Enforce used nomenclature (sub, dom, obj, act)
@@ -122,6 +209,30 @@ func ResourceAuthorization(server *server.Server, resource string, action string
 	}
 }
 
+// Owned Resource Authorization: Check if resource action can be done, at least on resources the subject owns.
+// The handler must decide on the loaded resource with server.Authorize.
+func OwnedResourceAuthorization(server *server.Server, resource string, action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			user, err := util.ExtractSubject(e)
+			if err != nil {
+				return api.WebResponse(e, http.StatusUnauthorized, api.FIELD_VALIDATION_ERROR("User not found in context"))
+			}
+			domain := e.Request().Header.Get("domain")
+
+			// A resource of the subject's own satisfies the owner condition of conditional policies
+			ok, err := server.Authorize(user, domain, resource, action, user)
+			if err != nil {
+				return api.WebResponse(e, http.StatusInternalServerError, api.FIELD_VALIDATION_ERROR("Casbin enforcement error"))
+			}
+			if !ok {
+				return api.WebResponse(e, http.StatusForbidden, api.CASBIN_UNAUTHORIZED())
+			}
+			return next(e)
+		}
+	}
+}
+
 // PerformanceMonitoringMw adds performance monitoring to requests
 func PerformanceMonitoringMw(server *server.Server) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -0,0 +1,190 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmHS512 = "HS512"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Key is a single signing key identified by its key ID (kid)
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// NewHMACKey creates a symmetric key from a shared secret
+func NewHMACKey(kid, secret string) (*Key, error) {
+	if secret == "" {
+		return nil, errors.New("empty HMAC secret")
+	}
+	if kid == "" {
+		sum := sha256.Sum256([]byte("kid:" + secret))
+		kid = base64.RawURLEncoding.EncodeToString(sum[:12])
+	}
+	return &Key{ID: kid, Method: jwt.SigningMethodHS512, private: []byte(secret), public: []byte(secret)}, nil
+}
+
+// NewPrivateKey creates an asymmetric key from a PEM encoded private key
+func NewPrivateKey(kid, algorithm string, pemBytes []byte) (*Key, error) {
+	key := &Key{ID: kid}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+		key.Method, key.private, key.public = jwt.SigningMethodRS256, private, &private.PublicKey
+	case AlgorithmEdDSA:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 private key: %w", err)
+		}
+		edPrivate, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("not an Ed25519 private key")
+		}
+		key.Method, key.private, key.public = jwt.SigningMethodEdDSA, edPrivate, edPrivate.Public()
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+
+	if key.ID == "" {
+		key.ID = key.thumbprint()
+	}
+	return key, nil
+}
+
+// LoadPrivateKey reads a PEM encoded private key from file
+func LoadPrivateKey(kid, algorithm, path string) (*Key, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key %s: %w", path, err)
+	}
+	return NewPrivateKey(kid, algorithm, pemBytes)
+}
+
+// Symmetric reports whether the key is a shared secret that must never be published
+func (key *Key) Symmetric() bool {
+	_, ok := key.public.([]byte)
+	return ok
+}
+
+// PublicKey returns the key used for verification
+func (key *Key) PublicKey() crypto.PublicKey {
+	return key.public
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as default key ID
+func (key *Key) thumbprint() string {
+	jwk := key.JWK()
+	var members string
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, jwk.Crv, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// KeyRing holds the active signing key together with keys only used for verification
+type KeyRing struct {
+	mu     sync.RWMutex
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewKeyRing creates a key ring signing with the active key
+func NewKeyRing(active *Key, verificationKeys ...*Key) *KeyRing {
+	ring := &KeyRing{}
+	ring.Replace(active, verificationKeys...)
+	return ring
+}
+
+// Replace swaps all keys of the ring at once
+func (ring *KeyRing) Replace(active *Key, verificationKeys ...*Key) {
+	keys := map[string]*Key{active.ID: active}
+	for _, key := range verificationKeys {
+		keys[key.ID] = key
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	ring.active = active
+	ring.keys = keys
+}
+
+// Active returns the key new tokens are signed with
+func (ring *KeyRing) Active() *Key {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.active
+}
+
+// Lookup finds a key by its key ID
+func (ring *KeyRing) Lookup(kid string) (*Key, bool) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	key, ok := ring.keys[kid]
+	return key, ok
+}
+
+// Keys returns all keys of the ring, the active key first
+func (ring *KeyRing) Keys() []*Key {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	keys := make([]*Key, 0, len(ring.keys))
+	for _, key := range ring.keys {
+		if key != ring.active {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return append([]*Key{ring.active}, keys...)
+}
+
+// Sign signs the claims with the active key and sets the kid header
+func (ring *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	key := ring.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// Keyfunc resolves the verification key of a token by its kid header.
+// Tokens without kid were issued before key IDs existed and are checked against the active key.
+func (ring *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key := ring.Active()
+	if kid != "" {
+		var ok bool
+		if key, ok = ring.Lookup(kid); !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
@@ -0,0 +1,136 @@
+package keys
+
+import (
+	"fmt"
+	"goweb/config"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ReloadInterval is how often running instances check the key ring manifest for changes
+const ReloadInterval = time.Minute
+
+// LoadAccessKeyRing builds the key ring for access tokens.
+// The key ring manifest takes precedence over the key configured through the environment.
+func LoadAccessKeyRing(cfg config.AuthConfig) (*KeyRing, error) {
+	if ring, err := loadManifestRing(cfg, RingAccess); ring != nil || err != nil {
+		return ring, err
+	}
+
+	key, err := ConfiguredAccessKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyRing(key), nil
+}
+
+// LoadRefreshKeyRing builds the key ring for refresh tokens.
+// The key ring manifest takes precedence over the key configured through the environment.
+func LoadRefreshKeyRing(cfg config.AuthConfig) (*KeyRing, error) {
+	if ring, err := loadManifestRing(cfg, RingRefresh); ring != nil || err != nil {
+		return ring, err
+	}
+
+	key, err := ConfiguredRefreshKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyRing(key), nil
+}
+
+// ConfiguredAccessKey loads the access token key configured through the environment
+func ConfiguredAccessKey(cfg config.AuthConfig) (*Key, error) {
+	var key *Key
+	var err error
+
+	switch cfg.JWT.Algorithm {
+	case "", AlgorithmHS512:
+		key, err = NewHMACKey(cfg.JWT.KeyID, cfg.AccessSecret)
+	case AlgorithmRS256, AlgorithmEdDSA:
+		if cfg.JWT.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", cfg.JWT.Algorithm)
+		}
+		key, err = LoadPrivateKey(cfg.JWT.KeyID, cfg.JWT.Algorithm, cfg.JWT.PrivateKeyFile)
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM: %s", cfg.JWT.Algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load access token key: %w", err)
+	}
+	return key, nil
+}
+
+// ConfiguredRefreshKey loads the refresh token key configured through the environment.
+// Refresh tokens are only verified by this service and use a shared secret.
+func ConfiguredRefreshKey(cfg config.AuthConfig) (*Key, error) {
+	key, err := NewHMACKey("", cfg.RefreshSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load refresh token key: %w", err)
+	}
+	return key, nil
+}
+
+// WatchKeyRings reloads both key rings whenever the manifest changes, so keys
+// generated, promoted or retired with the keys command take effect without a restart.
+func WatchKeyRings(cfg config.AuthConfig, access, refresh *KeyRing) {
+	if cfg.JWT.KeyRingDir == "" {
+		return
+	}
+
+	go func() {
+		var lastModified time.Time
+		if info, err := os.Stat(filepath.Join(cfg.JWT.KeyRingDir, ManifestFile)); err == nil {
+			lastModified = info.ModTime()
+		}
+
+		for range time.Tick(ReloadInterval) {
+			info, err := os.Stat(filepath.Join(cfg.JWT.KeyRingDir, ManifestFile))
+			if err != nil || !info.ModTime().After(lastModified) {
+				continue
+			}
+			lastModified = info.ModTime()
+
+			if err := reload(cfg, access, refresh); err != nil {
+				log.Error().Err(err).Str("event", "key_ring_reload_failed").Msg("Failed to reload key rings, keeping current keys")
+				continue
+			}
+			log.Info().Str("event", "key_ring_reloaded").Str("access_kid", access.Active().ID).
+				Str("refresh_kid", refresh.Active().ID).Msg("Key rings reloaded")
+		}
+	}()
+}
+
+// reload swaps in both rings only after both were built successfully
+func reload(cfg config.AuthConfig, access, refresh *KeyRing) error {
+	newAccess, err := LoadAccessKeyRing(cfg)
+	if err != nil {
+		return err
+	}
+	newRefresh, err := LoadRefreshKeyRing(cfg)
+	if err != nil {
+		return err
+	}
+
+	access.Replace(newAccess.Active(), newAccess.Keys()...)
+	refresh.Replace(newRefresh.Active(), newRefresh.Keys()...)
+	return nil
+}
+
+// loadManifestRing builds the named ring from the manifest, nil when the manifest does not define it
+func loadManifestRing(cfg config.AuthConfig, name string) (*KeyRing, error) {
+	if cfg.JWT.KeyRingDir == "" {
+		return nil, nil
+	}
+
+	manifest, err := LoadManifest(cfg.JWT.KeyRingDir)
+	if err != nil {
+		return nil, err
+	}
+	if ring, _ := manifest.Ring(name); len(ring.Keys) == 0 {
+		return nil, nil
+	}
+	return manifest.Build(name)
+}
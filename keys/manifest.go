@@ -0,0 +1,279 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFile is the name of the manifest describing the keys of a key ring directory
+const ManifestFile = "keyring.json"
+
+// Names of the key rings kept in the manifest
+const (
+	RingAccess  = "access"
+	RingRefresh = "refresh"
+)
+
+// Lifecycle of a key in the manifest.
+// Pending keys verify tokens but do not sign yet, giving every instance time to load them before promotion.
+// Retiring keys were replaced by a newer key and only verify tokens issued before the promotion.
+const (
+	StatusPending  = "pending"
+	StatusActive   = "active"
+	StatusRetiring = "retiring"
+)
+
+// ManifestKey describes one key of a ring, the key material is stored in File next to the manifest
+type ManifestKey struct {
+	ID          string     `json:"kid"`
+	Algorithm   string     `json:"alg"`
+	File        string     `json:"file"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RetireAfter *time.Time `json:"retire_after,omitempty"`
+}
+
+// RingManifest lists the keys of a single key ring
+type RingManifest struct {
+	Keys []ManifestKey `json:"keys"`
+}
+
+// Manifest is the on-disk description of the access and refresh key rings
+type Manifest struct {
+	Access  RingManifest `json:"access"`
+	Refresh RingManifest `json:"refresh"`
+	dir     string
+}
+
+// LoadManifest reads the manifest of the directory, a missing manifest yields an empty one
+func LoadManifest(dir string) (*Manifest, error) {
+	manifest := &Manifest{dir: dir}
+
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key ring manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse key ring manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Save atomically writes the manifest, so running instances never read a partial file
+func (m *Manifest) Save() error {
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(m.dir, ManifestFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, ManifestFile))
+}
+
+// Ring returns the manifest of the named key ring
+func (m *Manifest) Ring(name string) (*RingManifest, error) {
+	switch name {
+	case RingAccess:
+		return &m.Access, nil
+	case RingRefresh:
+		return &m.Refresh, nil
+	}
+	return nil, fmt.Errorf("unknown key ring: %s", name)
+}
+
+// Build loads the key material and assembles the key ring
+func (m *Manifest) Build(name string) (*KeyRing, error) {
+	ring, err := m.Ring(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var active *Key
+	var verificationKeys []*Key
+	for _, entry := range ring.Keys {
+		key, err := m.loadKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Status == StatusActive {
+			active = key
+		} else {
+			verificationKeys = append(verificationKeys, key)
+		}
+	}
+
+	if active == nil {
+		return nil, fmt.Errorf("key ring %s has no active key", name)
+	}
+	return NewKeyRing(active, verificationKeys...), nil
+}
+
+// Import adds an existing key to the ring, used to take over the key configured through the environment
+func (m *Manifest) Import(name string, key *Key, status string) error {
+	ring, err := m.Ring(name)
+	if err != nil {
+		return err
+	}
+
+	material, ext, err := key.marshal()
+	if err != nil {
+		return err
+	}
+
+	entry := ManifestKey{
+		ID:        key.ID,
+		Algorithm: key.Method.Alg(),
+		File:      name + "-" + key.ID + ext,
+		Status:    status,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, entry.File), material, 0600); err != nil {
+		return fmt.Errorf("failed to write key %s: %w", key.ID, err)
+	}
+
+	ring.Keys = append(ring.Keys, entry)
+	return nil
+}
+
+// Promote makes the key the active signing key. The previously active key keeps
+// verifying tokens until retireAfter has passed, when its last tokens have expired.
+func (m *Manifest) Promote(name, kid string, retireAfter time.Duration) error {
+	ring, err := m.Ring(name)
+	if err != nil {
+		return err
+	}
+
+	index := ring.find(kid)
+	if index < 0 {
+		return fmt.Errorf("key %s not found in key ring %s", kid, name)
+	}
+	if ring.Keys[index].Status == StatusActive {
+		return fmt.Errorf("key %s is already active", kid)
+	}
+
+	retireAt := time.Now().UTC().Add(retireAfter)
+	for i := range ring.Keys {
+		if ring.Keys[i].Status == StatusActive {
+			ring.Keys[i].Status = StatusRetiring
+			ring.Keys[i].RetireAfter = &retireAt
+		}
+	}
+	ring.Keys[index].Status = StatusActive
+	ring.Keys[index].RetireAfter = nil
+	return nil
+}
+
+// Retire removes a key from the ring and deletes its key material.
+// Keys whose tokens may still be valid are only removed when forced.
+func (m *Manifest) Retire(name, kid string, force bool) error {
+	ring, err := m.Ring(name)
+	if err != nil {
+		return err
+	}
+
+	index := ring.find(kid)
+	if index < 0 {
+		return fmt.Errorf("key %s not found in key ring %s", kid, name)
+	}
+
+	entry := ring.Keys[index]
+	switch {
+	case entry.Status == StatusActive:
+		return fmt.Errorf("key %s is active, promote another key first", kid)
+	case !force && entry.RetireAfter != nil && time.Now().Before(*entry.RetireAfter):
+		return fmt.Errorf("tokens signed with key %s are valid until %s", kid, entry.RetireAfter.Format(time.RFC3339))
+	}
+
+	ring.Keys = append(ring.Keys[:index], ring.Keys[index+1:]...)
+	if err := os.Remove(filepath.Join(m.dir, entry.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (ring *RingManifest) find(kid string) int {
+	for i, entry := range ring.Keys {
+		if entry.ID == kid {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Manifest) loadKey(entry ManifestKey) (*Key, error) {
+	material, err := os.ReadFile(filepath.Join(m.dir, entry.File))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", entry.ID, err)
+	}
+	if entry.Algorithm == AlgorithmHS512 {
+		return NewHMACKey(entry.ID, string(material))
+	}
+	return NewPrivateKey(entry.ID, entry.Algorithm, material)
+}
+
+// GenerateKey creates a new random key for the algorithm
+func GenerateKey(algorithm string) (*Key, error) {
+	switch algorithm {
+	case AlgorithmHS512:
+		secret := make([]byte, 64)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return NewHMACKey("", base64.RawURLEncoding.EncodeToString(secret))
+	case AlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return newGeneratedKey(algorithm, private)
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return newGeneratedKey(algorithm, private)
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+}
+
+func newGeneratedKey(algorithm string, private interface{}) (*Key, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey("", algorithm, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// marshal encodes the key material for storage, returning the file extension to use
+func (key *Key) marshal() ([]byte, string, error) {
+	if secret, ok := key.private.([]byte); ok {
+		return secret, ".key", nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return nil, "", err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), ".pem", nil
+}
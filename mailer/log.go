@@ -0,0 +1,16 @@
+package mailer
+
+import "github.com/rs/zerolog/log"
+
+// LogMailer writes emails to the log instead of sending them, for development only
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (LogMailer) Send(message Message) error {
+	log.Info().Str("event", "mail_logged").Str("to", message.To).Str("subject", message.Subject).
+		Str("body", message.Body).Msg("Email not sent, MAIL_DRIVER is log")
+	return nil
+}
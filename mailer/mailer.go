@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"fmt"
+	"goweb/config"
+)
+
+// Drivers selectable with MAIL_DRIVER
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverSMTP = "smtp"
+)
+
+const DefaultFrom = "no-reply@localhost"
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails
+type Mailer interface {
+	Send(message Message) error
+}
+
+// New returns the mailer of the configured driver
+func New(cfg config.MailConfig) (Mailer, error) {
+	from := cfg.From
+	if from == "" {
+		from = DefaultFrom
+	}
+
+	switch cfg.Driver {
+	case "", DriverLog:
+		return NewLogMailer(), nil
+	case DriverFile:
+		return NewFileMailer(cfg.FileDir, from)
+	case DriverSMTP:
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, from)
+	}
+	return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Driver)
+}
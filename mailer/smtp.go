@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends emails through an SMTP server, authenticating when a user is configured
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host, port, user, password, from string) (*SMTPMailer, error) {
+	if host == "" {
+		return nil, errors.New("SMTP_HOST is not configured")
+	}
+	if port == "" {
+		port = "587"
+	}
+
+	mailer := &SMTPMailer{addr: net.JoinHostPort(host, port), from: from}
+	if user != "" {
+		mailer.auth = smtp.PlainAuth("", user, password, host)
+	}
+	return mailer, nil
+}
+
+func (mailer *SMTPMailer) Send(message Message) error {
+	return smtp.SendMail(mailer.addr, mailer.auth, mailer.from, []string{message.To}, compose(mailer.from, message))
+}
+
+// compose renders the message in RFC 5322 format
+func compose(from string, message Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", sanitizeHeader(message.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", sanitizeHeader(message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+// sanitizeHeader drops line breaks, so values cannot inject headers
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// APIKey authenticates a machine client within a single domain.
+// Only the bcrypt hash of the secret is stored, the secret is shown once on creation.
+type APIKey struct {
+	BaseResource
+	Name       string     `json:"name" gorm:"type:varchar(200);"`
+	Key        string     `json:"key" gorm:"column:api_key;type:varchar(64);uniqueIndex"`
+	SecretHash string     `json:"-" gorm:"type:varchar(200);"`
+	Role       string     `json:"role" gorm:"type:varchar(64);"`
+	UserID     uint64     `json:"-"`
+	User       User       `json:"-" gorm:"foreignkey:UserID"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// Expired reports whether the key is past its expiry
+func (key *APIKey) Expired() bool {
+	return key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)
+}
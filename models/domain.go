@@ -1,7 +1,12 @@
 package models
 
+// SystemDomain is the name of the domain holding the roles and role policies shared by all domains
+const SystemDomain = "System"
+
 type Domain struct {
 	Base
-	Name  string  `json:"name" gorm:"type:text"`
-	Users []*User `json:"users,omitempty" gorm:"many2many:domain_users;"`
+	Name                 string  `json:"name" gorm:"type:text"`
+	RequireMFA           bool    `json:"require_mfa" gorm:"default:false"`            // Members must sign in with two-factor authentication
+	RequireVerifiedEmail bool    `json:"require_verified_email" gorm:"default:false"` // Members must verify their email first
+	Users                []*User `json:"users,omitempty" gorm:"many2many:domain_users;"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Identity is a social or OpenID Connect account linked to a user, a user can link any number of them.
+// Provider and Subject identify the account at its provider, the email is the one it reported on the last login.
+type Identity struct {
+	Base
+	UserID     uint64     `json:"-" gorm:"index"`
+	Provider   string     `json:"provider" gorm:"type:varchar(50);uniqueIndex:idx_identities_provider_subject"`
+	Subject    string     `json:"-" gorm:"type:varchar(255);uniqueIndex:idx_identities_provider_subject"`
+	Email      string     `json:"email" gorm:"type:varchar(200);"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RecoveryCode is a single-use code to sign in when the authenticator is unavailable.
+// Only the bcrypt hash of the code is stored.
+type RecoveryCode struct {
+	ID        uint64 `gorm:"primarykey"`
+	UserID    uint64 `gorm:"index"`
+	CodeHash  string `gorm:"type:varchar(200);"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
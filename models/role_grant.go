@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Reasons a role grant ended
+const (
+	GrantExpired     = "expired"
+	GrantRevoked     = "revoked"
+	GrantRoleDeleted = "role_deleted"
+)
+
+// RoleGrant is a time-bound role assignment. The Casbin grouping rule assigning the role exists from the
+// grant until it ends, the enforcer ignores it before ValidFrom and from ValidUntil on. Ended grants are
+// kept as the history of temporary access.
+type RoleGrant struct {
+	Base
+	Subject    string     `json:"subject" gorm:"type:char(36);index"` // UUID of the user
+	Role       string     `json:"role" gorm:"type:varchar(64);"`
+	Domain     string     `json:"domain" gorm:"type:char(36);index"`
+	ValidFrom  time.Time  `json:"valid_from"`
+	ValidUntil time.Time  `json:"valid_until" gorm:"index"`
+	GrantedBy  string     `json:"granted_by" gorm:"type:char(36);"`
+	EndedAt    *time.Time `json:"ended_at"`
+	EndReason  string     `json:"end_reason,omitempty" gorm:"type:varchar(32);"`
+}
+
+// ActiveAt reports whether the grant is in effect at the time
+func (grant *RoleGrant) ActiveAt(at time.Time) bool {
+	return grant.EndedAt == nil && !at.Before(grant.ValidFrom) && at.Before(grant.ValidUntil)
+}
@@ -12,9 +12,11 @@ type User struct {
 	Name       string    `json:"name" gorm:"type:varchar(200);"`
 	Password   string    `json:"-" gorm:"type:varchar(200);"`
 	Avatar     string    `json:"avatar" gorm:"type:varchar(500);"`
-	Provider   string    `json:"provider" gorm:"type:varchar(50);default:'local'"` // local, google, github, etc.
-	ProviderID string    `json:"provider_id" gorm:"type:varchar(200);"`            // ID from the OAuth provider
+	Provider   string    `json:"provider" gorm:"type:varchar(50);default:'local'"` // Provider the account was created with, linked accounts are in identities
+	ProviderID string    `json:"provider_id" gorm:"type:varchar(200);"`            // ID from the OAuth provider the account was created with
 	IsVerified bool      `json:"is_verified" gorm:"default:false"`                 // Email verification status
+	MFAEnabled bool      `json:"mfa_enabled" gorm:"default:false"`                 // TOTP two-factor authentication enrolled
+	TOTPSecret string    `json:"-" gorm:"type:varchar(64);"`                       // Base32 TOTP secret
 	Domains    []*Domain `json:"domains,omitempty" gorm:"many2many:domain_users;"`
 	Posts      []*Post   `json:"posts,omitempty"`
 }
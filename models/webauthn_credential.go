@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// WebAuthnCredential is a passkey registered by a user.
+// Only the public key is stored, the private key never leaves the authenticator.
+type WebAuthnCredential struct {
+	Base
+	UserID          uint64     `json:"-" gorm:"index"`
+	Name            string     `json:"name" gorm:"type:varchar(200);"`
+	CredentialID    string     `json:"-" gorm:"type:varchar(255);uniqueIndex"` // Base64url encoded credential ID
+	PublicKey       []byte     `json:"-"`                                      // COSE encoded public key
+	AttestationType string     `json:"-" gorm:"type:varchar(32);"`
+	Transports      string     `json:"-" gorm:"type:varchar(200);"` // Comma separated authenticator transports
+	AAGUID          []byte     `json:"-"`
+	Flags           uint8      `json:"-"`                  // Authenticator flags of the last ceremony
+	SignCount       uint32     `json:"-" gorm:"default:0"` // Signature counter, used to detect cloned authenticators
+	LastUsedAt      *time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,19 @@
+package requests
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type CreateAPIKeyRequest struct {
+	Name          string `form:"name" json:"name" validate:"required" example:"CI pipeline"`
+	Role          string `form:"role" json:"role" validate:"required" example:"Operator"`
+	ExpiresInDays int    `form:"expires_in_days" json:"expires_in_days" example:"90"`
+}
+
+func (ar CreateAPIKeyRequest) Validate() error {
+	return validation.ValidateStruct(&ar,
+		validation.Field(&ar.Name, validation.Required, validation.Length(1, 200)),
+		validation.Field(&ar.Role, validation.Required),
+		validation.Field(&ar.ExpiresInDays, validation.Min(0), validation.Max(3650)),
+	)
+}
@@ -11,3 +11,8 @@ func (r CreateDomainRequest) Validate() error {
 		validation.Field(&r.Name, validation.Required, validation.Length(1, 100)),
 	)
 }
+
+type DomainSettingsRequest struct {
+	RequireMFA           bool `form:"require_mfa" json:"require_mfa" example:"true"`
+	RequireVerifiedEmail bool `form:"require_verified_email" json:"require_verified_email" example:"true"`
+}
@@ -0,0 +1,27 @@
+package requests
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type MFACodeRequest struct {
+	Code string `form:"code" json:"code" validate:"required" example:"123456"`
+}
+
+func (mr MFACodeRequest) Validate() error {
+	return validation.ValidateStruct(&mr,
+		validation.Field(&mr.Code, validation.Required),
+	)
+}
+
+type MFALoginRequest struct {
+	MFAToken string `form:"mfa_token" json:"mfa_token" validate:"required" example:"mfa_token"`
+	Code     string `form:"code" json:"code" validate:"required" example:"123456"`
+}
+
+func (mr MFALoginRequest) Validate() error {
+	return validation.ValidateStruct(&mr,
+		validation.Field(&mr.MFAToken, validation.Required),
+		validation.Field(&mr.Code, validation.Required),
+	)
+}
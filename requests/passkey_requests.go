@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"encoding/json"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// PasskeyRegistrationRequest finishes a passkey registration with the result of navigator.credentials.create
+type PasskeyRegistrationRequest struct {
+	Session    string          `form:"session" json:"session" validate:"required" example:"passkey_session"`
+	Name       string          `form:"name" json:"name" example:"MacBook Touch ID"`
+	Credential json.RawMessage `form:"credential" json:"credential" validate:"required" swaggertype:"object"`
+}
+
+func (pr PasskeyRegistrationRequest) Validate() error {
+	return validation.ValidateStruct(&pr,
+		validation.Field(&pr.Session, validation.Required),
+		validation.Field(&pr.Name, validation.Length(0, 200)),
+		validation.Field(&pr.Credential, validation.Required),
+	)
+}
+
+// PasskeyLoginRequest finishes a passkey login with the result of navigator.credentials.get
+type PasskeyLoginRequest struct {
+	Session    string          `form:"session" json:"session" validate:"required" example:"passkey_session"`
+	Credential json.RawMessage `form:"credential" json:"credential" validate:"required" swaggertype:"object"`
+}
+
+func (pr PasskeyLoginRequest) Validate() error {
+	return validation.ValidateStruct(&pr,
+		validation.Field(&pr.Session, validation.Required),
+		validation.Field(&pr.Credential, validation.Required),
+	)
+}
@@ -0,0 +1,28 @@
+package requests
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `form:"email" json:"email" validate:"required" example:"username@gmail.com"`
+}
+
+func (fr ForgotPasswordRequest) Validate() error {
+	return validation.ValidateStruct(&fr,
+		validation.Field(&fr.Email, validation.Required, is.Email),
+	)
+}
+
+type ResetPasswordRequest struct {
+	Token    string `form:"token" json:"token" validate:"required" example:"reset_token"`
+	Password string `form:"password" json:"password" validate:"required" example:"P@ssw0r6"`
+}
+
+func (rr ResetPasswordRequest) Validate() error {
+	return validation.ValidateStruct(&rr,
+		validation.Field(&rr.Token, validation.Required),
+		validation.Field(&rr.Password, validation.Required, validation.Length(minPathLength, 0)),
+	)
+}
@@ -0,0 +1,98 @@
+package requests
+
+import (
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+// policyName matches role, object and action names, they are stored verbatim as Casbin policy fields
+var policyName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// PermissionRequest grants or revokes an action on an object type for a role of the caller's domain,
+// removals pass the fields as query parameters. With a condition the action is only granted on resources
+// meeting it, "owner" limits it to the subject's own resources (see server.ConditionOwner).
+type PermissionRequest struct {
+	Role      string `form:"role" json:"role" query:"role" validate:"required" example:"Manager"`
+	Object    string `form:"object" json:"object" query:"object" validate:"required" example:"Post"`
+	Action    string `form:"action" json:"action" query:"action" validate:"required" example:"Create"`
+	Condition string `form:"condition" json:"condition" query:"condition" example:"owner"`
+}
+
+func (pr PermissionRequest) Validate() error {
+	return validation.ValidateStruct(&pr,
+		validation.Field(&pr.Role, validation.Required, validation.Length(1, 64), validation.Match(policyName)),
+		validation.Field(&pr.Object, validation.Required, validation.Length(1, 64), validation.Match(policyName)),
+		validation.Field(&pr.Action, validation.Required, validation.Length(1, 64), validation.Match(policyName)),
+		validation.Field(&pr.Condition, validation.In("owner")),
+	)
+}
+
+// PermissionCheck is one action on an object type the caller wants to know whether it may perform,
+// optionally on a resource owned by the given user UUID
+type PermissionCheck struct {
+	Resource string `json:"resource" validate:"required" example:"Post"`
+	Action   string `json:"action" validate:"required" example:"Create"`
+	Owner    string `json:"owner" example:"0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"`
+}
+
+func (pc PermissionCheck) Validate() error {
+	return validation.ValidateStruct(&pc,
+		validation.Field(&pc.Resource, validation.Required, validation.Length(1, 64), validation.Match(policyName)),
+		validation.Field(&pc.Action, validation.Required, validation.Length(1, 64), validation.Match(policyName)),
+		validation.Field(&pc.Owner, is.UUID),
+	)
+}
+
+// PermissionCheckRequest checks several permissions of the caller at once
+type PermissionCheckRequest struct {
+	Checks []PermissionCheck `json:"checks" validate:"required"`
+}
+
+func (pcr PermissionCheckRequest) Validate() error {
+	return validation.ValidateStruct(&pcr,
+		validation.Field(&pcr.Checks, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// ExplainRequest asks why a subject may or may not perform an action on an object type in a domain.
+// The subject is a user or API key UUID or a user's email, the domain defaults to the request domain.
+// The owner, the UUID of the user a resource belongs to, lets conditional permissions decide.
+type ExplainRequest struct {
+	Subject string         `json:"subject" validate:"required" example:"userb@localhost"`
+	Domain  string         `json:"domain" example:"5f0c7a52-8f5e-4bb3-9d0e-0d8a2c4a1f11"`
+	Object  string         `json:"object" validate:"required" example:"Post"`
+	Action  string         `json:"action" validate:"required" example:"Create"`
+	Owner   string         `json:"owner" example:"0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"`
+	WhatIf  *WhatIfRequest `json:"what_if"`
+}
+
+func (er ExplainRequest) Validate() error {
+	return validation.ValidateStruct(&er,
+		validation.Field(&er.Subject, validation.Required, validation.Length(1, 200)),
+		validation.Field(&er.Domain, is.UUID),
+		validation.Field(&er.Object, validation.Required, validation.Length(1, 64), validation.Match(policyName)),
+		validation.Field(&er.Action, validation.Required, validation.Length(1, 64), validation.Match(policyName)),
+		validation.Field(&er.Owner, is.UUID),
+		validation.Field(&er.WhatIf),
+	)
+}
+
+// WhatIfRequest proposes changes to the domain's policies and the subject's roles, the decision is
+// made as if they were saved
+type WhatIfRequest struct {
+	AddPolicies    []PermissionRequest `json:"add_policies"`
+	RemovePolicies []PermissionRequest `json:"remove_policies"`
+	AddRoles       []string            `json:"add_roles" example:"Manager"`
+	RemoveRoles    []string            `json:"remove_roles" example:"Operator"`
+}
+
+func (wr WhatIfRequest) Validate() error {
+	return validation.ValidateStruct(&wr,
+		validation.Field(&wr.AddPolicies, validation.Length(0, 100)),
+		validation.Field(&wr.RemovePolicies, validation.Length(0, 100)),
+		validation.Field(&wr.AddRoles, validation.Length(0, 100), validation.Each(validation.Required, validation.Length(1, 64), validation.Match(policyName))),
+		validation.Field(&wr.RemoveRoles, validation.Length(0, 100), validation.Each(validation.Required, validation.Length(1, 64), validation.Match(policyName))),
+	)
+}
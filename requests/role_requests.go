@@ -1,15 +1,57 @@
 package requests
 
 import (
+	"errors"
+	"time"
+
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
+// RoleRequest names a role and the role it inherits the permissions of, an empty parent removes the inheritance
 type RoleRequest struct {
-	Name string `form:"name" json:"name" validate:"required" example:"Operator"`
+	Name   string `form:"name" json:"name" validate:"required" example:"Manager"`
+	Parent string `form:"parent" json:"parent" example:"Operator"`
 }
 
 func (rr RoleRequest) Validate() error {
 	return validation.ValidateStruct(&rr,
 		validation.Field(&rr.Name, validation.Required),
+		validation.Field(&rr.Parent, validation.Length(0, 64)),
+	)
+}
+
+// UserRoleRequest names a role of the caller's domain, to assign it to a user or to reset its override,
+// resets pass the role as query parameter
+type UserRoleRequest struct {
+	Role string `form:"role" json:"role" query:"role" validate:"required" example:"Manager"`
+}
+
+func (ur UserRoleRequest) Validate() error {
+	return validation.ValidateStruct(&ur,
+		validation.Field(&ur.Role, validation.Required, validation.Length(1, 64)),
+	)
+}
+
+// RoleAssignmentRequest names a role of the caller's domain to assign to a user, permanently or, with valid_until,
+// for a limited time starting at valid_from or right away
+type RoleAssignmentRequest struct {
+	Role       string     `json:"role" validate:"required" example:"Manager"`
+	ValidFrom  *time.Time `json:"valid_from" example:"2026-01-01T08:00:00Z"`
+	ValidUntil *time.Time `json:"valid_until" example:"2026-01-01T18:00:00Z"`
+}
+
+func (ar RoleAssignmentRequest) Validate() error {
+	return validation.ValidateStruct(&ar,
+		validation.Field(&ar.Role, validation.Required, validation.Length(1, 64)),
+		validation.Field(&ar.ValidFrom, validation.When(ar.ValidUntil == nil, validation.Nil.Error("requires valid_until"))),
+		validation.Field(&ar.ValidUntil, validation.When(ar.ValidUntil != nil, validation.By(func(interface{}) error {
+			if !ar.ValidUntil.After(time.Now()) {
+				return errors.New("must be in the future")
+			}
+			if ar.ValidFrom != nil && !ar.ValidUntil.After(*ar.ValidFrom) {
+				return errors.New("must be after valid_from")
+			}
+			return nil
+		}))),
 	)
 }
@@ -0,0 +1,26 @@
+package requests
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+type VerifyEmailRequest struct {
+	Token string `form:"token" json:"token" validate:"required" example:"verification_token"`
+}
+
+func (vr VerifyEmailRequest) Validate() error {
+	return validation.ValidateStruct(&vr,
+		validation.Field(&vr.Token, validation.Required),
+	)
+}
+
+type ResendVerificationRequest struct {
+	Email string `form:"email" json:"email" validate:"required" example:"username@gmail.com"`
+}
+
+func (rr ResendVerificationRequest) Validate() error {
+	return validation.ValidateStruct(&rr,
+		validation.Field(&rr.Email, validation.Required, is.Email),
+	)
+}
@@ -0,0 +1,57 @@
+package responses
+
+import (
+	"goweb/models"
+	"time"
+)
+
+type APIKeyResponse struct {
+	UUID       string  `json:"uuid" example:"uuid"`
+	Name       string  `json:"name" example:"CI pipeline"`
+	Key        string  `json:"key" example:"3f2a9c0e5b7d4e61a8c9d0e1f2a3b4c5"`
+	Role       string  `json:"role" example:"Operator"`
+	Owner      string  `json:"owner" example:"John Doe"`
+	CreatedAt  string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	ExpiresAt  *string `json:"expires_at" example:"2023-04-01T00:00:00Z"`
+	LastUsedAt *string `json:"last_used_at" example:"2023-01-02T00:00:00Z"`
+}
+
+// APIKeySecretResponse is returned once on creation, the secret cannot be retrieved later
+type APIKeySecretResponse struct {
+	APIKeyResponse
+	Secret string `json:"secret" example:"9b1c...e4"`
+}
+
+func NewAPIKeyResponse(apiKeys []models.APIKey) *[]APIKeyResponse {
+	apiKeyResponse := make([]APIKeyResponse, 0)
+
+	for i := range apiKeys {
+		apiKeyResponse = append(apiKeyResponse, APIKeyResponse{
+			UUID:       apiKeys[i].UUID.String(),
+			Name:       apiKeys[i].Name,
+			Key:        apiKeys[i].Key,
+			Role:       apiKeys[i].Role,
+			Owner:      apiKeys[i].User.Name,
+			CreatedAt:  apiKeys[i].CreatedAt.Format(time.RFC3339),
+			ExpiresAt:  formatOptionalTime(apiKeys[i].ExpiresAt),
+			LastUsedAt: formatOptionalTime(apiKeys[i].LastUsedAt),
+		})
+	}
+
+	return &apiKeyResponse
+}
+
+func NewAPIKeySecretResponse(apiKey models.APIKey, secret string) APIKeySecretResponse {
+	return APIKeySecretResponse{
+		APIKeyResponse: (*NewAPIKeyResponse([]models.APIKey{apiKey}))[0],
+		Secret:         secret,
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
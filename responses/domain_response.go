@@ -0,0 +1,19 @@
+package responses
+
+import "goweb/models"
+
+type DomainSettingsResponse struct {
+	UUID                 string `json:"uuid" example:"uuid"`
+	Name                 string `json:"name" example:"Reliance"`
+	RequireMFA           bool   `json:"require_mfa" example:"true"`
+	RequireVerifiedEmail bool   `json:"require_verified_email" example:"true"`
+}
+
+func NewDomainSettingsResponse(domain *models.Domain) *DomainSettingsResponse {
+	return &DomainSettingsResponse{
+		UUID:                 domain.UUID.String(),
+		Name:                 domain.Name,
+		RequireMFA:           domain.RequireMFA,
+		RequireVerifiedEmail: domain.RequireVerifiedEmail,
+	}
+}
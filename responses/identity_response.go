@@ -0,0 +1,39 @@
+package responses
+
+import (
+	"goweb/models"
+	"time"
+)
+
+// IdentityLinkResponse starts linking a provider account, send the browser to the URL
+type IdentityLinkResponse struct {
+	URL string `json:"url" example:"https://accounts.google.com/o/oauth2/auth?client_id=..."`
+}
+
+func NewIdentityLinkResponse(url string) *IdentityLinkResponse {
+	return &IdentityLinkResponse{URL: url}
+}
+
+type IdentityResponse struct {
+	UUID       string  `json:"uuid" example:"uuid"`
+	Provider   string  `json:"provider" example:"google"`
+	Email      string  `json:"email" example:"john.doe@example.com"`
+	CreatedAt  string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	LastUsedAt *string `json:"last_used_at" example:"2023-01-02T00:00:00Z"`
+}
+
+func NewIdentityResponse(identities []models.Identity) *[]IdentityResponse {
+	identityResponse := make([]IdentityResponse, 0)
+
+	for i := range identities {
+		identityResponse = append(identityResponse, IdentityResponse{
+			UUID:       identities[i].UUID.String(),
+			Provider:   identities[i].Provider,
+			Email:      identities[i].Email,
+			CreatedAt:  identities[i].CreatedAt.Format(time.RFC3339),
+			LastUsedAt: formatOptionalTime(identities[i].LastUsedAt),
+		})
+	}
+
+	return &identityResponse
+}
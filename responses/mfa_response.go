@@ -0,0 +1,26 @@
+package responses
+
+// MFAChallengeResponse is returned by login when the user has to provide a second factor
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"mfa_token"`
+	Exp         int64  `json:"exp"`
+}
+
+func NewMFAChallengeResponse(token string, exp int64) *MFAChallengeResponse {
+	return &MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		Exp:         exp,
+	}
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/Ohmex:username@gmail.com?secret=JBSWY3DPEHPK3PXP&issuer=Ohmex"`
+}
+
+// RecoveryCodesResponse lists recovery codes, they are shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"4f1c2-9ab07"`
+}
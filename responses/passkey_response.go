@@ -0,0 +1,48 @@
+package responses
+
+import (
+	"goweb/models"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+// PasskeyCeremonyResponse starts a passkey ceremony, pass the options to the browser
+// and send its result together with the session to the matching finish endpoint
+type PasskeyCeremonyResponse struct {
+	Session string      `json:"session" example:"passkey_session"`
+	Options interface{} `json:"options" swaggertype:"object"`
+	Exp     int64       `json:"exp"`
+}
+
+func NewPasskeyCeremonyResponse(session string, options interface{}, exp int64) *PasskeyCeremonyResponse {
+	return &PasskeyCeremonyResponse{
+		Session: session,
+		Options: options,
+		Exp:     exp,
+	}
+}
+
+type PasskeyResponse struct {
+	UUID       string  `json:"uuid" example:"uuid"`
+	Name       string  `json:"name" example:"MacBook Touch ID"`
+	Synced     bool    `json:"synced" example:"true"`
+	CreatedAt  string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	LastUsedAt *string `json:"last_used_at" example:"2023-01-02T00:00:00Z"`
+}
+
+func NewPasskeyResponse(credentials []models.WebAuthnCredential) *[]PasskeyResponse {
+	passkeyResponse := make([]PasskeyResponse, 0)
+
+	for i := range credentials {
+		passkeyResponse = append(passkeyResponse, PasskeyResponse{
+			UUID:       credentials[i].UUID.String(),
+			Name:       credentials[i].Name,
+			Synced:     protocol.AuthenticatorFlags(credentials[i].Flags).HasBackupState(),
+			CreatedAt:  credentials[i].CreatedAt.Format(time.RFC3339),
+			LastUsedAt: formatOptionalTime(credentials[i].LastUsedAt),
+		})
+	}
+
+	return &passkeyResponse
+}
@@ -0,0 +1,129 @@
+package responses
+
+import (
+	"goweb/requests"
+	"sort"
+)
+
+type PermissionResponse struct {
+	Role      string `json:"role" example:"Manager"`
+	Object    string `json:"object" example:"Post"`
+	Action    string `json:"action" example:"Create"`
+	Condition string `json:"condition,omitempty" example:"owner"` // only on resources meeting the condition
+	Inherited bool   `json:"inherited" example:"false"`           // template of the System domain
+}
+
+// NewPermissionResponse maps Casbin policies (sub, dom, obj, act) and conditional policies (sub, dom, obj, act, cond)
+// of the domain and the templates it inherits
+func NewPermissionResponse(policies [][]string, domain string) *[]PermissionResponse {
+	permissionResponse := make([]PermissionResponse, 0)
+
+	for _, policy := range policies {
+		if len(policy) < 4 {
+			continue
+		}
+		permissionResponse = append(permissionResponse, PermissionResponse{
+			Role:      policy[0],
+			Object:    policy[2],
+			Action:    policy[3],
+			Condition: condition(policy),
+			Inherited: policy[1] != domain,
+		})
+	}
+
+	return &permissionResponse
+}
+
+// EffectivePermissionResponse is an action on an object type the caller may perform, with a condition
+// only on the resources meeting it
+type EffectivePermissionResponse struct {
+	Resource  string `json:"resource" example:"Post"`
+	Action    string `json:"action" example:"Create"`
+	Condition string `json:"condition,omitempty" example:"owner"`
+}
+
+// PermissionCheckResponse answers one check of a batch, in the order of the request
+type PermissionCheckResponse struct {
+	Resource string `json:"resource" example:"Post"`
+	Action   string `json:"action" example:"Create"`
+	Allowed  bool   `json:"allowed" example:"true"`
+}
+
+// NewEffectivePermissionResponse maps implicit Casbin permissions (sub, dom, obj, act[, cond]) to distinct
+// object and action pairs, sorted by object and action. Conditional permissions are left out for pairs
+// granted outright.
+func NewEffectivePermissionResponse(policies [][]string) *[]EffectivePermissionResponse {
+	effectivePermissionResponse := make([]EffectivePermissionResponse, 0)
+	unconditional := map[[2]string]bool{}
+	for _, policy := range policies {
+		if len(policy) == 4 {
+			unconditional[[2]string{policy[2], policy[3]}] = true
+		}
+	}
+	seen := map[[3]string]bool{}
+
+	for _, policy := range policies {
+		if len(policy) < 4 {
+			continue
+		}
+		key := [3]string{policy[2], policy[3], condition(policy)}
+		if seen[key] || key[2] != "" && unconditional[[2]string{policy[2], policy[3]}] {
+			continue
+		}
+		seen[key] = true
+		effectivePermissionResponse = append(effectivePermissionResponse, EffectivePermissionResponse{
+			Resource:  policy[2],
+			Action:    policy[3],
+			Condition: key[2],
+		})
+	}
+
+	sort.Slice(effectivePermissionResponse, func(i, j int) bool {
+		a, b := effectivePermissionResponse[i], effectivePermissionResponse[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Action != b.Action {
+			return a.Action < b.Action
+		}
+		return a.Condition < b.Condition
+	})
+	return &effectivePermissionResponse
+}
+
+// condition returns the condition of a conditional policy, empty for unconditional ones
+func condition(policy []string) string {
+	if len(policy) > 4 {
+		return policy[4]
+	}
+	return ""
+}
+
+// NewPermissionCheckResponse pairs the checks with the enforcer's decisions
+func NewPermissionCheckResponse(checks []requests.PermissionCheck, allowed []bool) *[]PermissionCheckResponse {
+	permissionCheckResponse := make([]PermissionCheckResponse, 0, len(checks))
+
+	for i, check := range checks {
+		permissionCheckResponse = append(permissionCheckResponse, PermissionCheckResponse{
+			Resource: check.Resource,
+			Action:   check.Action,
+			Allowed:  i < len(allowed) && allowed[i],
+		})
+	}
+
+	return &permissionCheckResponse
+}
+
+// ExplainResponse is a policy decision together with the rule and role chain it is based on
+type ExplainResponse struct {
+	Allowed bool     `json:"allowed" example:"true"`
+	Subject string   `json:"subject" example:"0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"`
+	Domain  string   `json:"domain" example:"5f0c7a52-8f5e-4bb3-9d0e-0d8a2c4a1f11"`
+	Object  string   `json:"object" example:"Post"`
+	Action  string   `json:"action" example:"Create"`
+	Owner   string   `json:"owner,omitempty" example:"0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a"`
+	Rule    []string `json:"rule" example:"Manager,5f0c7a52-8f5e-4bb3-9d0e-0d8a2c4a1f11,Post,Create"`
+	Chain   []string `json:"chain" example:"0b6f1c2e-5d4a-4f7b-9a43-2f1e8d7c6b5a,Manager"`
+	Roles   []string `json:"roles" example:"Manager"`
+	WhatIf  bool     `json:"what_if" example:"false"`
+}
@@ -7,6 +7,7 @@ import (
 type PostResponse struct {
 	UUID      string `json:"uuid" example:"uuid"`
 	Username  string `json:"username" example:"John Doe"`
+	Owner     string `json:"owner" example:"uuid"` // UUID of the user who wrote the post
 	Title     string `json:"title" example:"Echo"`
 	Content   string `json:"content" example:"Echo is nice!"`
 	CreatedAt string `json:"created_at" example:"2023-01-01T00:00:00Z"`
@@ -20,6 +21,7 @@ func NewPostResponse(posts []models.Post) *[]PostResponse {
 		postResponse = append(postResponse, PostResponse{
 			UUID:      posts[i].UUID.String(),
 			Username:  posts[i].User.Name,
+			Owner:     posts[i].User.UUID.String(),
 			Title:     posts[i].Title,
 			Content:   posts[i].Content,
 			CreatedAt: posts[i].CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
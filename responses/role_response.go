@@ -0,0 +1,22 @@
+package responses
+
+import "goweb/models"
+
+// RoleResponse is a role with the role it inherits from, the permissions granted to it directly and the
+// permissions it has in effect, including those of the roles it inherits from
+type RoleResponse struct {
+	*models.Role
+	Parent               string               `json:"parent" example:"Operator"`
+	Permissions          []PermissionResponse `json:"permissions"`
+	EffectivePermissions []PermissionResponse `json:"effective_permissions"`
+}
+
+// NewRoleResponse maps the role's direct and effective Casbin policies (sub, dom, obj, act) in the domain
+func NewRoleResponse(role *models.Role, parent string, direct, effective [][]string, domain string) *RoleResponse {
+	return &RoleResponse{
+		Role:                 role,
+		Parent:               parent,
+		Permissions:          *NewPermissionResponse(direct, domain),
+		EffectivePermissions: *NewPermissionResponse(effective, domain),
+	}
+}
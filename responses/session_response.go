@@ -0,0 +1,34 @@
+package responses
+
+import (
+	"goweb/services"
+	"time"
+)
+
+type SessionResponse struct {
+	ID         string `json:"id" example:"uuid"`
+	Device     string `json:"device" example:"iPhone"`
+	UserAgent  string `json:"user_agent" example:"Mozilla/5.0"`
+	IP         string `json:"ip" example:"203.0.113.7"`
+	Current    bool   `json:"current" example:"true"`
+	CreatedAt  string `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	LastSeenAt string `json:"last_seen_at" example:"2023-01-01T00:00:00Z"`
+}
+
+func NewSessionResponse(sessions []*services.Session, currentID string) *[]SessionResponse {
+	sessionResponse := make([]SessionResponse, 0)
+
+	for _, session := range sessions {
+		sessionResponse = append(sessionResponse, SessionResponse{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID == currentID,
+			CreatedAt:  time.Unix(session.CreatedAt, 0).Format("2006-01-02T15:04:05Z07:00"),
+			LastSeenAt: time.Unix(session.LastSeenAt, 0).Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	return &sessionResponse
+}
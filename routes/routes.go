@@ -27,9 +27,9 @@ func ConfigureRoutes(server *server.Server) {
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
 			return new(services.JwtCustomClaims)
 		},
-		ContextKey:    "token",
-		SigningMethod: jwt.SigningMethodHS512.Name,
-		SigningKey:    []byte(server.Config.Auth.AccessSecret),
+		ContextKey: "token",
+		KeyFunc:    server.AccessKeys.Keyfunc,
+		Skipper:    interceptor.APIKeyAuthenticated,
 	}
 
 	// Global middleware
@@ -37,7 +37,7 @@ func ConfigureRoutes(server *server.Server) {
 	server.Echo.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:3000"},
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, services.DeviceHeader, interceptor.APIKeyHeader, interceptor.APISecretHeader},
 		AllowCredentials: true,
 	}))
 	server.Echo.Use(middleware.GzipWithConfig(middleware.GzipConfig{
@@ -50,14 +50,19 @@ func ConfigureRoutes(server *server.Server) {
 	server.Echo.Use(interceptor.PerformanceMonitoringMw(server))
 
 	// Middleware assignments for later use
+	server.APIKeyAuthenticationMw = interceptor.APIKeyAuthenticationMw(server)
 	server.JwtAuthenticationMw = echojwt.WithConfig(jwtConfig)
 	server.JwtClaimsAuthorizationMw = interceptor.JwtClaimsAuthorizationMw(server)
 	server.CasbinAuthorizationMw = interceptor.CasbinAuthorization(server)
+	server.MFAEnforcementMw = interceptor.MFAEnforcementMw(server)
 
 	// Global Handlers
 	authHandler := handlers.NewAuthHandler(server)
 	registerHandler := handlers.NewRegisterHandler(server)
 	socialHandler := handlers.NewSocialHandler(server)
+	jwksHandler := handlers.NewJWKSHandler(server)
+	passwordHandler := handlers.NewPasswordHandler(server)
+	verificationHandler := handlers.NewVerificationHandler(server)
 
 	// Public routes
 	server.Echo.GET("/", func(ctx echo.Context) error {
@@ -99,14 +104,25 @@ func ConfigureRoutes(server *server.Server) {
 
 	server.Echo.Static("/swagger", "docs")
 	server.Echo.POST("/login", authHandler.Login)
+	server.Echo.POST("/login/mfa", authHandler.LoginMFA)
+	server.Echo.POST("/login/passkey/begin", authHandler.LoginPasskeyBegin)
+	server.Echo.POST("/login/passkey/finish", authHandler.LoginPasskeyFinish)
 	server.Echo.POST("/register", registerHandler.Register)
 	server.Echo.POST("/refresh", authHandler.RefreshToken)
+	server.Echo.POST("/password/forgot", passwordHandler.Forgot)
+	server.Echo.POST("/password/reset", passwordHandler.Reset)
+	server.Echo.POST("/verify-email", verificationHandler.Verify)
+	server.Echo.POST("/verify-email/resend", verificationHandler.Resend)
+	server.Echo.GET("/.well-known/jwks.json", jwksHandler.JWKS)
 
 	// Social login routes
 	server.Echo.GET("/auth/google", socialHandler.GoogleLogin)
 	server.Echo.GET("/auth/google/callback", socialHandler.GoogleCallback)
 	server.Echo.GET("/auth/github", socialHandler.GitHubLogin)
 	server.Echo.GET("/auth/github/callback", socialHandler.GitHubCallback)
+	// OpenID Connect providers from OIDC_PROVIDERS, the static routes above take precedence
+	server.Echo.GET("/auth/:provider", socialHandler.OIDCLogin)
+	server.Echo.GET("/auth/:provider/callback", socialHandler.OIDCCallback)
 
 	// Protected routes group
 	protected := server.Echo.Group("")
@@ -117,13 +133,70 @@ func ConfigureRoutes(server *server.Server) {
 	roleHandler := handlers.NewRoleHandler(server)
 	userHandler := handlers.NewUserHandler(server)
 	postHandler := handlers.NewPostHandler(server)
+	sessionHandler := handlers.NewSessionHandler(server)
+	apiKeyHandler := handlers.NewAPIKeyHandler(server)
+	mfaHandler := handlers.NewMFAHandler(server)
+	passkeyHandler := handlers.NewPasskeyHandler(server)
+	identityHandler := handlers.NewIdentityHandler(server)
+	domainHandler := handlers.NewDomainHandler(server)
+	permissionHandler := handlers.NewPermissionHandler(server)
+
+	// Sessions of the current user
+	protected.GET("/sessions", sessionHandler.ListMine)
+	protected.DELETE("/sessions", sessionHandler.RevokeAllMine)
+	protected.DELETE("/sessions/:uuid", sessionHandler.RevokeMine)
+
+	// Two-factor authentication of the current user
+	protected.POST("/mfa/totp", mfaHandler.Enroll)
+	protected.POST("/mfa/totp/confirm", mfaHandler.Confirm)
+	protected.DELETE("/mfa/totp", mfaHandler.Disable)
+	protected.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+
+	// Passkeys of the current user
+	protected.GET("/passkeys", passkeyHandler.List)
+	protected.POST("/passkeys/register/begin", passkeyHandler.BeginRegistration)
+	protected.POST("/passkeys/register/finish", passkeyHandler.FinishRegistration)
+	protected.DELETE("/passkeys/:uuid", passkeyHandler.Delete)
+
+	// Social and OpenID Connect accounts linked to the current user
+	protected.GET("/identities", identityHandler.List)
+	protected.POST("/identities/:provider", socialHandler.Link)
+	protected.DELETE("/identities/:uuid", identityHandler.Unlink)
 
 	// API resource routes grouped under /api
 	api := server.Echo.Group("/api")
-	api.Use(server.JwtAuthenticationMw, server.JwtClaimsAuthorizationMw, server.CasbinAuthorizationMw)
+	api.Use(server.APIKeyAuthenticationMw, server.JwtAuthenticationMw, server.JwtClaimsAuthorizationMw, server.MFAEnforcementMw, server.CasbinAuthorizationMw)
 	addResource(api, "/role", roleHandler, server)
 	addResource(api, "/user", userHandler, server)
-	addResource(api, "/post", postHandler, server)
+	addOwnedResource(api, "/post", postHandler, server)
+	addResource(api, "/session", sessionHandler, server)
+	addResource(api, "/apikey", apiKeyHandler, server)
+	api.DELETE("/user/:uuid/lockout", userHandler.Unlock, interceptor.ResourceAuthorization(server, userHandler.Type(), "Update"))
+	api.GET("/user/:uuid/role", userHandler.ListRoles, interceptor.ResourceAuthorization(server, "UserRole", "List"))
+	api.GET("/user/:uuid/role/grants", userHandler.ListRoleGrants, interceptor.ResourceAuthorization(server, "UserRole", "List"))
+	api.POST("/user/:uuid/role", userHandler.AssignRole, interceptor.ResourceAuthorization(server, "UserRole", "Create"))
+	api.DELETE("/user/:uuid/role/:role", userHandler.UnassignRole, interceptor.ResourceAuthorization(server, "UserRole", "Delete"))
+	api.GET("/domain/settings", domainHandler.ReadSettings, interceptor.ResourceAuthorization(server, "DomainSettings", "Read"))
+	api.PUT("/domain/settings", domainHandler.UpdateSettings, interceptor.ResourceAuthorization(server, "DomainSettings", "Update"))
+	api.DELETE("/session", sessionHandler.DeleteAll, interceptor.ResourceAuthorization(server, sessionHandler.Type(), "Delete"))
+	api.GET("/permission", permissionHandler.List, interceptor.ResourceAuthorization(server, permissionHandler.Type(), "List"))
+	api.POST("/permission", permissionHandler.Create, interceptor.ResourceAuthorization(server, permissionHandler.Type(), "Create"))
+	api.DELETE("/permission", permissionHandler.Delete, interceptor.ResourceAuthorization(server, permissionHandler.Type(), "Delete"))
+	api.DELETE("/permission/override", permissionHandler.ResetOverride, interceptor.ResourceAuthorization(server, permissionHandler.Type(), "Delete"))
+	api.POST("/permission/explain", permissionHandler.Explain, interceptor.ResourceAuthorization(server, permissionHandler.Type(), "Explain"))
+	api.GET("/me/permissions", permissionHandler.Mine)
+	api.POST("/me/permissions/check", permissionHandler.CheckMine)
+}
+
+// addOwnedResource adds RESTful resource routes like addResource, updates and deletes are also let through for
+// subjects allowed them on their own resources only, the handler checks the owner of the loaded resource
+func addOwnedResource(group *echo.Group, p string, h handlers.BaseInterface, server *server.Server) {
+	sub := group.Group(p)
+	sub.GET("", h.List, interceptor.ResourceAuthorization(server, h.Type(), "List"))                   // List resources
+	sub.GET("/:uuid", h.Read, interceptor.ResourceAuthorization(server, h.Type(), "Read"))             // Read resource
+	sub.POST("", h.Create, interceptor.ResourceAuthorization(server, h.Type(), "Create"))              // Create resource
+	sub.PUT("/:uuid", h.Update, interceptor.OwnedResourceAuthorization(server, h.Type(), "Update"))    // Update own or any resource
+	sub.DELETE("/:uuid", h.Delete, interceptor.OwnedResourceAuthorization(server, h.Type(), "Delete")) // Delete own or any resource
 }
 
 // addResource adds RESTful resource routes to the given group
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"goweb/models"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"gorm.io/gorm"
+)
+
+// ConditionalPolicy is the policy type of permissions granted under a condition on the resource's
+// attributes, (role, domain, object, action, condition), decided by the m2 matcher
+const ConditionalPolicy = "p2"
+
+// ConditionOwner limits a conditional policy to resources owned by the subject
+const ConditionOwner = "owner"
+
+// conditions are the conditions conditional policies can name, each decides on the subject and the
+// owner of the resource
+var conditions = map[string]func(subject, owner string) bool{
+	ConditionOwner: func(subject, owner string) bool { return owner != "" && owner == subject },
+}
+
+// RoleTemplateDomain is the domain of the role hierarchy links between roles of the System domain. Like
+// the System domain's policies they are templates, the links apply in every domain.
+const RoleTemplateDomain = "*"
+
+// ConfigureEnforcer registers the functions the matcher of casbin/model.conf calls and lets the links of
+// RoleTemplateDomain apply in every domain. The enforcer checks assignments against the time-bound role grants,
+// enforcers working on copies of the same rules share them.
+//
+// inheritsTemplate(role, policyDomain, requestDomain) lets the policies of a role in the System domain
+// apply to every domain that does not define policies of that role itself. A domain overrides a
+// template by defining the role's policies locally, those replace the template's policies there.
+// Conditional policies count as the role's policies like unconditional ones.
+//
+// condition(name, subject, owner) decides the condition a conditional policy names.
+//
+// grantActive(subject, role, domain) checks that the subject has the role through an assignment in effect,
+// time-bound grants only are between their ValidFrom and ValidUntil. Policies of the subject itself always apply.
+//
+// The functions run during enforcement, a synced enforcer's lock is held already, so they are registered on the
+// enforcer it wraps.
+func ConfigureEnforcer(enforcer *casbin.Enforcer, db *gorm.DB, grants *RoleGrants) {
+	var (
+		mu     sync.Mutex
+		system string
+	)
+	// The System domain is created by the first migration, it is looked up until it exists
+	systemUUID := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		if system == "" {
+			var domain models.Domain
+			if db.Where("name = ?", models.SystemDomain).First(&domain).Error == nil {
+				system = domain.UUID.String()
+			}
+		}
+		return system
+	}
+
+	enforcer.AddFunction("inheritsTemplate", func(args ...interface{}) (interface{}, error) {
+		if len(args) != 3 {
+			return false, nil
+		}
+		role, _ := args[0].(string)
+		policyDomain, _ := args[1].(string)
+		requestDomain, _ := args[2].(string)
+		if policyDomain == "" || policyDomain != systemUUID() {
+			return false, nil
+		}
+		local, err := enforcer.GetFilteredPolicy(0, role, requestDomain)
+		if err != nil || len(local) > 0 {
+			return false, nil
+		}
+		local, err = enforcer.GetFilteredNamedPolicy(ConditionalPolicy, 0, role, requestDomain)
+		return err == nil && len(local) == 0, nil
+	})
+
+	enforcer.AddFunction("condition", func(args ...interface{}) (interface{}, error) {
+		if len(args) != 3 {
+			return false, nil
+		}
+		name, _ := args[0].(string)
+		subject, _ := args[1].(string)
+		owner, _ := args[2].(string)
+		condition, ok := conditions[name]
+		return ok && condition(subject, owner), nil
+	})
+
+	enforcer.AddFunction("grantActive", func(args ...interface{}) (interface{}, error) {
+		if len(args) != 3 {
+			return false, nil
+		}
+		subject, _ := args[0].(string)
+		role, _ := args[1].(string)
+		domain, _ := args[2].(string)
+		// Policies written on the subject itself need no assignment
+		if role == subject || !grants.Bounded(subject, domain) {
+			return true, nil
+		}
+		roles, err := ActiveRoles(enforcer, grants, subject, domain)
+		if err != nil {
+			return false, nil
+		}
+		for _, active := range roles {
+			if active == role {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+
+	// Users are assigned roles in their domains only, RoleTemplateDomain holds links between roles
+	enforcer.AddNamedDomainMatchingFunc("g", "roleTemplates", func(domain, pattern string) bool {
+		return pattern == RoleTemplateDomain
+	})
+}
+
+// ActiveRoles returns the roles the subject has in the domain through assignments in effect now, together
+// with the roles those inherit from
+func ActiveRoles(enforcer casbin.IEnforcer, grants *RoleGrants, subject, domain string) ([]string, error) {
+	assigned, err := enforcer.GetRolesForUser(subject, domain)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	seen := map[string]bool{}
+	roles := []string{}
+	for _, role := range assigned {
+		if !grants.Active(subject, role, domain, now) {
+			continue
+		}
+		inherited, err := enforcer.GetImplicitRolesForUser(role, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range append([]string{role}, inherited...) {
+			if !seen[name] {
+				seen[name] = true
+				roles = append(roles, name)
+			}
+		}
+	}
+	return roles, nil
+}
+
+// Authorize decides whether the subject may perform the action on a resource of the object type in the
+// domain. Unless a policy grants the action outright, the conditional policies decide on the resource's
+// owner, the UUID of the user it belongs to.
+func (server *Server) Authorize(subject, domain, object, action, owner string) (bool, error) {
+	allowed, err := server.Casbin.Enforce(subject, domain, object, action)
+	if err != nil || allowed {
+		return allowed, err
+	}
+	return server.Casbin.Enforce(casbin.NewEnforceContext("2"), subject, domain, object, action, owner)
+}
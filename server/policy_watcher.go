@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// PolicyChannel is the Redis channel the instances announce Casbin policy changes on
+const PolicyChannel = "casbin:policy"
+
+const (
+	policyPingInterval  = 30 * time.Second // idle time after which the subscription is checked
+	policyRetryInterval = time.Second      // wait between attempts while Redis is unreachable
+)
+
+// PolicyWatcher keeps the enforcers of all instances in sync. Every change made through an enforcer
+// is announced on PolicyChannel, the other instances reload their policies when they receive it.
+// Announcements sent while an instance was disconnected are lost, so instances reload after every
+// reconnect and repeat their own announcement if it could not be published.
+type PolicyWatcher struct {
+	redis    *redis.Client
+	instance string
+	callback func(string)
+	mu       sync.RWMutex
+	pending  atomic.Bool
+	cancel   context.CancelFunc
+}
+
+// NewPolicyWatcher subscribes to PolicyChannel until the watcher is closed
+func NewPolicyWatcher(client *redis.Client) *PolicyWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := &PolicyWatcher{redis: client, instance: uuid.NewString(), cancel: cancel}
+	go watcher.listen(ctx)
+	return watcher
+}
+
+// SetUpdateCallback sets the function reloading the policies, the enforcer sets it to LoadPolicy
+func (w *PolicyWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+// Update announces a policy change of this instance. The change is already saved, so a failed
+// announcement is not returned to the caller but repeated once Redis is reachable again.
+func (w *PolicyWatcher) Update() error {
+	if err := w.redis.Publish(context.Background(), PolicyChannel, w.instance).Err(); err != nil {
+		w.pending.Store(true)
+		log.Warn().Str("event", "casbin_watcher_publish_failed").Err(err).Msg("Failed to announce policy change, retrying after reconnect")
+	}
+	return nil
+}
+
+// Close stops the subscription
+func (w *PolicyWatcher) Close() {
+	w.cancel()
+}
+
+func (w *PolicyWatcher) listen(ctx context.Context) {
+	pubsub := w.redis.Subscribe(ctx, PolicyChannel)
+	defer pubsub.Close()
+
+	lost := false
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, policyPingInterval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// A timeout only means the channel was quiet, unless the ping fails as well.
+			// Receiving again reconnects and subscribes again.
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if err = pubsub.Ping(ctx); err == nil {
+					continue
+				}
+			}
+			if !lost {
+				log.Warn().Str("event", "casbin_watcher_disconnected").Err(err).Msg("Lost policy change subscription, reconnecting")
+			}
+			lost = true
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(policyRetryInterval):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind != "subscribe" || !lost {
+				continue
+			}
+			lost = false
+			log.Info().Str("event", "casbin_watcher_reconnected").Msg("Policy change subscription restored, reloading policies")
+			w.notify("reconnected")
+			if w.pending.Swap(false) {
+				w.Update()
+			}
+		case *redis.Message:
+			if msg.Payload != w.instance {
+				w.notify(msg.Payload)
+			}
+		}
+	}
+}
+
+func (w *PolicyWatcher) notify(source string) {
+	w.mu.RLock()
+	callback := w.callback
+	w.mu.RUnlock()
+	if callback != nil {
+		callback(source)
+	}
+}
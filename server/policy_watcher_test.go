@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"goweb/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/casbin/casbin/v2"
+	ga "github.com/casbin/gorm-adapter/v3"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Reloads announced by other instances run while requests enforce, run with -race
+func TestWatcherReloadWhileEnforcing(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	database, err := gorm.Open(sqlite.Open("file:watcher?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := database.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := database.AutoMigrate(&models.Domain{}, &models.RoleGrant{}); err != nil {
+		t.Fatal(err)
+	}
+	adapter, err := ga.NewAdapterByDBUseTableName(database, "", "casbin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enforcer, err := casbin.NewSyncedEnforcer("../casbin/model.conf", adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants := NewRoleGrants(database)
+	ConfigureEnforcer(enforcer.Enforcer, database, grants)
+	watcher := NewPolicyWatcher(client)
+	t.Cleanup(watcher.Close)
+	WatchPolicies(enforcer, watcher, grants)
+
+	// The other instance saves its changes to the same rules and announces them
+	other, err := casbin.NewEnforcer("../casbin/model.conf", adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					enforcer.Enforce("alice", "domain", "Post", "Read")
+				}
+			}
+		}()
+	}
+
+	ctx := context.Background()
+	const changes = 20
+	for i := 0; i < changes; i++ {
+		if _, err := other.AddPolicy(fmt.Sprintf("role%d", i), "domain", "Post", "Read"); err != nil {
+			t.Fatal(err)
+		}
+		client.Publish(ctx, PolicyChannel, "other")
+	}
+
+	// Announcements made before the subscription was ready are lost, the last one is repeated until it arrives
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if ok, _ := enforcer.HasPolicy(fmt.Sprintf("role%d", changes-1), "domain", "Post", "Read"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("policies not reloaded")
+		}
+		client.Publish(ctx, PolicyChannel, "other")
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+}
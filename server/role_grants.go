@@ -0,0 +1,86 @@
+package server
+
+import (
+	"goweb/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RoleGrants caches the validity windows of the time-bound role grants still in place, the enforcer
+// checks the subject's assignments against them at request time. Assignments without grant are
+// permanent. The cache is reloaded whenever grants change, locally or announced by PolicyWatcher.
+type RoleGrants struct {
+	db       *gorm.DB
+	mu       sync.RWMutex
+	windows  map[grantKey]models.RoleGrant
+	subjects map[[2]string]bool // subject and domain having time-bound grants
+}
+
+type grantKey struct {
+	subject, role, domain string
+}
+
+// NewRoleGrants loads the grants that have not ended. The table does not exist before the migrations ran,
+// there are no grants then.
+func NewRoleGrants(db *gorm.DB) *RoleGrants {
+	grants := &RoleGrants{db: db, windows: map[grantKey]models.RoleGrant{}, subjects: map[[2]string]bool{}}
+	_ = grants.Reload()
+	return grants
+}
+
+// Reload loads the grants that have not ended
+func (grants *RoleGrants) Reload() error {
+	if grants == nil {
+		return nil
+	}
+	var rows []models.RoleGrant
+	if err := grants.db.Where("ended_at IS NULL").Find(&rows).Error; err != nil {
+		return err
+	}
+	windows := make(map[grantKey]models.RoleGrant, len(rows))
+	subjects := make(map[[2]string]bool, len(rows))
+	for _, row := range rows {
+		windows[grantKey{row.Subject, row.Role, row.Domain}] = row
+		subjects[[2]string{row.Subject, row.Domain}] = true
+	}
+
+	grants.mu.Lock()
+	defer grants.mu.Unlock()
+	grants.windows = windows
+	grants.subjects = subjects
+	return nil
+}
+
+// Active reports whether the subject's assignment of the role in the domain is in effect at the time
+func (grants *RoleGrants) Active(subject, role, domain string, at time.Time) bool {
+	if grants == nil {
+		return true
+	}
+	grants.mu.RLock()
+	defer grants.mu.RUnlock()
+	grant, ok := grants.windows[grantKey{subject, role, domain}]
+	return !ok || grant.ActiveAt(at)
+}
+
+// Grant returns the subject's time-bound grant of the role in the domain, if the assignment has one
+func (grants *RoleGrants) Grant(subject, role, domain string) (models.RoleGrant, bool) {
+	if grants == nil {
+		return models.RoleGrant{}, false
+	}
+	grants.mu.RLock()
+	defer grants.mu.RUnlock()
+	grant, ok := grants.windows[grantKey{subject, role, domain}]
+	return grant, ok
+}
+
+// Bounded reports whether the subject has time-bound grants in the domain
+func (grants *RoleGrants) Bounded(subject, domain string) bool {
+	if grants == nil {
+		return false
+	}
+	grants.mu.RLock()
+	defer grants.mu.RUnlock()
+	return grants.subjects[[2]string{subject, domain}]
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"goweb/config"
 	"goweb/db"
+	"goweb/keys"
+	"goweb/mailer"
 	"time"
 
 	"github.com/casbin/casbin/v2"
@@ -10,6 +12,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -18,20 +21,50 @@ type Server struct {
 	DB                       *gorm.DB
 	Redis                    *redis.Client
 	Config                   *config.Config
-	Casbin                   *casbin.Enforcer
+	Casbin                   *casbin.SyncedEnforcer
+	PolicyWatcher            *PolicyWatcher
+	RoleGrants               *RoleGrants
+	AccessKeys               *keys.KeyRing
+	RefreshKeys              *keys.KeyRing
+	Mailer                   mailer.Mailer
+	APIKeyAuthenticationMw   echo.MiddlewareFunc
 	JwtAuthenticationMw      echo.MiddlewareFunc
 	JwtClaimsAuthorizationMw echo.MiddlewareFunc
 	CasbinAuthorizationMw    echo.MiddlewareFunc
+	MFAEnforcementMw         echo.MiddlewareFunc
 }
 
 func NewServer(cfg *config.Config) *Server {
 	database := db.InitDB(cfg)
 	//adaptor, _ := ga.NewAdapter("sqlite3", "casbin.db")
 	adaptor, _ := ga.NewAdapterByDBUseTableName(database, "", "casbin")
-	enforcer, _ := casbin.NewEnforcer("casbin/model.conf", adaptor)
+	// Requests enforce while the watcher reloads the policies, the synced enforcer serialises both
+	enforcer, _ := casbin.NewSyncedEnforcer("casbin/model.conf", adaptor)
+	roleGrants := NewRoleGrants(database)
+	ConfigureEnforcer(enforcer.Enforcer, database, roleGrants)
 	//enforcer.EnableLog(true)
 	enforcer.LoadPolicy()
 
+	// Announce policy changes to the other instances and reload theirs
+	redisClient := db.InitRedis(cfg)
+	policyWatcher := NewPolicyWatcher(redisClient)
+	WatchPolicies(enforcer, policyWatcher, roleGrants)
+
+	accessKeys, err := keys.LoadAccessKeyRing(cfg.Auth)
+	if err != nil {
+		panic(err.Error())
+	}
+	refreshKeys, err := keys.LoadRefreshKeyRing(cfg.Auth)
+	if err != nil {
+		panic(err.Error())
+	}
+	keys.WatchKeyRings(cfg.Auth, accessKeys, refreshKeys)
+
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		panic(err.Error())
+	}
+
 	e := echo.New()
 	e.HideBanner = false
 	e.HidePort = false
@@ -44,14 +77,34 @@ func NewServer(cfg *config.Config) *Server {
 	e.Server.MaxHeaderBytes = 1 << 20 // 1MB
 
 	return &Server{
-		Echo:   e,
-		DB:     database,
-		Redis:  db.InitRedis(cfg),
-		Config: cfg,
-		Casbin: enforcer,
+		Echo:          e,
+		DB:            database,
+		Redis:         redisClient,
+		Config:        cfg,
+		Casbin:        enforcer,
+		PolicyWatcher: policyWatcher,
+		RoleGrants:    roleGrants,
+		AccessKeys:    accessKeys,
+		RefreshKeys:   refreshKeys,
+		Mailer:        mail,
 	}
 }
 
+// WatchPolicies lets the watcher announce the enforcer's policy changes and reload its policies and the role
+// grants on the changes of other instances
+func WatchPolicies(enforcer *casbin.SyncedEnforcer, watcher *PolicyWatcher, grants *RoleGrants) {
+	enforcer.SetWatcher(watcher)
+	watcher.SetUpdateCallback(func(source string) {
+		if err := enforcer.LoadPolicy(); err != nil {
+			log.Error().Str("event", "casbin_reload_failed").Str("source", source).Err(err).Msg("Failed to reload Casbin policies")
+		}
+		// Grants change together with their assignments
+		if err := grants.Reload(); err != nil {
+			log.Error().Str("event", "role_grants_reload_failed").Str("source", source).Err(err).Msg("Failed to reload role grants")
+		}
+	})
+}
+
 func (server *Server) Start(addr string) error {
 	return server.Echo.Start(":" + addr)
 }
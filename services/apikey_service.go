@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"goweb/api"
+	"goweb/models"
+	"goweb/server"
+	"goweb/util"
+	"time"
+)
+
+// APIKeyVerifiedCacheKey caches a successful secret check, so bcrypt does not run on every request
+const APIKeyVerifiedCacheKey = "apikey:%s:verified"
+const APIKeyVerifiedCacheTTL = 5 * time.Minute
+
+type APIKeyService struct {
+	server *server.Server
+}
+
+func NewAPIKeyService(server *server.Server) *APIKeyService {
+	return &APIKeyService{server: server}
+}
+
+// Create stores the key and assigns its role in the domain, returning the plain secret
+func (service *APIKeyService) Create(apiKey *models.APIKey) (secret string, err error) {
+	key, secret, hash := util.GenerateAPIKeySecret()
+	apiKey.Key = key
+	apiKey.SecretHash = hash
+
+	if err = service.server.DB.Create(apiKey).Error; err != nil {
+		return "", api.RESOURCE_CREATION_FAILED("Failed to create API key")
+	}
+
+	if _, err = service.server.Casbin.AddRoleForUserInDomain(apiKey.UUID.String(), apiKey.Role, apiKey.Domain.String()); err != nil {
+		service.server.DB.Unscoped().Delete(apiKey)
+		return "", api.RESOURCE_CREATION_FAILED("Failed to assign API key role")
+	}
+
+	return secret, nil
+}
+
+func (service *APIKeyService) GetKeysInDomain(apiKeys *[]models.APIKey, domain *models.Domain) error {
+	return service.server.DB.Preload("User").Where("domain = ?", domain.UUID).Order("created_at desc").Find(apiKeys).Error
+}
+
+func (service *APIKeyService) GetKeyByUuidInDomain(apiKey *models.APIKey, uuid string, domain *models.Domain) error {
+	return service.server.DB.Preload("User").Where("uuid = ? AND domain = ?", uuid, domain.UUID).First(apiKey).Error
+}
+
+// Revoke deletes the key and its role assignment, requests using it fail immediately
+func (service *APIKeyService) Revoke(apiKey *models.APIKey) error {
+	if err := service.server.DB.Delete(apiKey).Error; err != nil {
+		return err
+	}
+	service.server.Redis.Del(context.Background(), fmt.Sprintf(APIKeyVerifiedCacheKey, apiKey.Key))
+	_, err := service.server.Casbin.DeleteRolesForUserInDomain(apiKey.UUID.String(), apiKey.Domain.String())
+	return err
+}
+
+// Authenticate resolves the key and checks its secret
+func (service *APIKeyService) Authenticate(key, secret string) (*models.APIKey, error) {
+	apiKey := new(models.APIKey)
+	if err := service.server.DB.Preload("User").Where("api_key = ?", key).First(apiKey).Error; err != nil {
+		return nil, api.INVALID_CREDENTIALS("Invalid API key")
+	}
+	if apiKey.User.ID == 0 {
+		return nil, api.INVALID_CREDENTIALS("API key owner no longer exists")
+	}
+	if apiKey.Expired() {
+		return nil, api.INVALID_CREDENTIALS("API key expired")
+	}
+
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(APIKeyVerifiedCacheKey, apiKey.Key)
+	digest := sha256.Sum256([]byte(secret))
+	fingerprint := hex.EncodeToString(digest[:])
+
+	if cached, err := service.server.Redis.Get(ctx, cacheKey).Result(); err == nil &&
+		subtle.ConstantTimeCompare([]byte(cached), []byte(fingerprint)) == 1 {
+		return apiKey, nil
+	}
+
+	if err := util.CheckPasswordHash(apiKey.SecretHash, secret); err != nil {
+		return nil, api.INVALID_CREDENTIALS("Invalid API key")
+	}
+	service.server.Redis.Set(ctx, cacheKey, fingerprint, APIKeyVerifiedCacheTTL)
+
+	return apiKey, nil
+}
+
+// Touch records the last use of the key
+func (service *APIKeyService) Touch(apiKey *models.APIKey) error {
+	return service.server.DB.Model(apiKey).UpdateColumn("last_used_at", time.Now()).Error
+}
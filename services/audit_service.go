@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// AuditEvent describes a security relevant action that must be traceable later on.
+type AuditEvent struct {
+	Event   string
+	UserID  uint64
+	Details map[string]interface{}
+}
+
+// AuditService emits audit events on a dedicated log channel so they can be
+// filtered and shipped independently from the regular request logs.
+type AuditService struct{}
+
+func NewAuditService() *AuditService {
+	return &AuditService{}
+}
+
+// Record writes the audit event to the audit log channel
+func (service *AuditService) Record(event AuditEvent) {
+	log.Warn().
+		Str("channel", "audit").
+		Str("event", event.Event).
+		Uint64("user_id", event.UserID).
+		Fields(event.Details).
+		Msg("Audit event")
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"fmt"
 	"goweb/models"
+	"goweb/requests"
 	"goweb/util"
 
 	"gorm.io/gorm"
@@ -22,6 +23,23 @@ func (service *DomainService) GetDomainByUUID(domain *models.Domain, id string)
 		First(domain).Error
 }
 
+// GetSystemDomain loads the domain holding the role templates of all domains
+func (service *DomainService) GetSystemDomain(domain *models.Domain) error {
+	return service.DB.
+		Where("name = ?", models.SystemDomain).
+		First(domain).Error
+}
+
+// UpdateSettings stores the settings of the domain
+func (service *DomainService) UpdateSettings(domain *models.Domain, request *requests.DomainSettingsRequest) error {
+	domain.RequireMFA = request.RequireMFA
+	domain.RequireVerifiedEmail = request.RequireVerifiedEmail
+	return service.DB.Model(domain).Updates(map[string]interface{}{
+		"require_mfa":            domain.RequireMFA,
+		"require_verified_email": domain.RequireVerifiedEmail,
+	}).Error
+}
+
 // CreateDomain creates a new domain and automatically creates a partition for it
 func (service *DomainService) CreateDomain(domain *models.Domain) error {
 	// Create the domain first
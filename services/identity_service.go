@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"goweb/api"
+	"goweb/models"
+	"goweb/server"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SocialProfile is the account a provider reported for a login
+type SocialProfile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	Name          string
+	Avatar        string
+	EmailVerified bool
+}
+
+// IdentityService manages the social and OpenID Connect accounts linked to users
+type IdentityService struct {
+	server *server.Server
+}
+
+func NewIdentityService(server *server.Server) *IdentityService {
+	return &IdentityService{server: server}
+}
+
+// ListForUser returns the identities linked to the user
+func (service *IdentityService) ListForUser(userID uint64) ([]models.Identity, error) {
+	var identities []models.Identity
+	err := service.server.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&identities).Error
+	return identities, err
+}
+
+// Find returns the identity of the provider account and its user
+func (service *IdentityService) Find(provider, subject string) (*models.Identity, *models.User, error) {
+	identity := new(models.Identity)
+	if err := service.server.DB.Where("provider = ? AND subject = ?", provider, subject).First(identity).Error; err != nil {
+		return nil, nil, err
+	}
+	user := new(models.User)
+	if err := service.server.DB.First(user, identity.UserID).Error; err != nil {
+		return nil, nil, err
+	}
+	return identity, user, nil
+}
+
+// Touch records a login with the identity
+func (service *IdentityService) Touch(identity *models.Identity, email string) {
+	now := time.Now()
+	service.server.DB.Model(identity).Updates(map[string]interface{}{"email": email, "last_used_at": &now})
+}
+
+// Link adds the provider account to the user, an account already linked to another user is refused
+func (service *IdentityService) Link(userID uint64, profile *SocialProfile) (*models.User, error) {
+	user := new(models.User)
+	if err := service.server.DB.First(user, userID).Error; err != nil {
+		return nil, api.USER_NOT_FOUND()
+	}
+
+	identity, owner, err := service.Find(profile.Provider, profile.Subject)
+	if err == nil {
+		if owner.ID != user.ID {
+			return nil, api.RESOURCE_EXISTS("This " + profile.Provider + " account is linked to another user")
+		}
+		service.Touch(identity, profile.Email)
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to link account")
+	}
+
+	now := time.Now()
+	identity = &models.Identity{
+		UserID:     user.ID,
+		Provider:   profile.Provider,
+		Subject:    profile.Subject,
+		Email:      profile.Email,
+		LastUsedAt: &now,
+	}
+	if err := service.server.DB.Create(identity).Error; err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to link account")
+	}
+	return user, nil
+}
+
+// Unlink removes an identity of the user, as long as a password, passkey or another identity is left to log in with
+func (service *IdentityService) Unlink(userID uint64, id string) (*models.Identity, error) {
+	identity := new(models.Identity)
+	err := service.server.DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the user serializes concurrent unlinks, each of them could otherwise see the other's identity as remaining
+		user := new(models.User)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(user, userID).Error; err != nil {
+			return api.USER_NOT_FOUND()
+		}
+		if err := tx.Where("uuid = ? AND user_id = ?", id, userID).First(identity).Error; err != nil {
+			return api.RESOURCE_NOT_FOUND("Linked account not found")
+		}
+
+		var identities, passkeys int64
+		tx.Model(&models.Identity{}).Where("user_id = ? AND id <> ?", userID, identity.ID).Count(&identities)
+		tx.Model(&models.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&passkeys)
+		if user.Password == "" && identities == 0 && passkeys == 0 {
+			return api.LAST_LOGIN_METHOD("Set a password or add a passkey before unlinking your last linked account")
+		}
+
+		// Deleted for good, so the provider account can be linked again
+		if err := tx.Unscoped().Delete(identity).Error; err != nil {
+			return api.INTERNAL_SERVICE_ERROR("Failed to unlink account")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"goweb/api"
+	"goweb/server"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// LoginFailuresCacheKey counts the failed logins of an account, keyed by the lower case email
+	LoginFailuresCacheKey = "login:failures:account:%s"
+	// LoginFailuresIPCacheKey counts the failed logins from an IP
+	LoginFailuresIPCacheKey = "login:failures:ip:%s"
+	// LoginBlockCacheKey blocks logins of an account until it expires, holding LoginBlockBackoff or LoginBlockLocked
+	LoginBlockCacheKey = "login:block:account:%s"
+	// LoginBlockIPCacheKey blocks logins from an IP until it expires
+	LoginBlockIPCacheKey = "login:block:ip:%s"
+)
+
+const (
+	LoginBlockBackoff = "backoff"
+	LoginBlockLocked  = "locked"
+)
+
+// LoginFailureWindowHours is how long failures are remembered without another failed attempt
+const LoginFailureWindowHours = 24
+
+// recordFailureScript counts a failed login and blocks further logins, for an exponentially growing
+// backoff after each failure and for the lockout duration once the maximum is reached
+var recordFailureScript = redis.NewScript(`
+local failures = redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+if failures >= tonumber(ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+	redis.call('SET', KEYS[2], 'locked', 'EX', ARGV[2])
+	return -1
+end
+local delay = math.min(math.floor(tonumber(ARGV[3]) * 2 ^ (failures - 1)), tonumber(ARGV[2]))
+if delay <= 0 then
+	return 0
+end
+redis.call('SET', KEYS[2], 'backoff', 'EX', delay)
+return delay
+`)
+
+// LoginThrottleService protects password logins against guessing by counting failures per account and per IP
+type LoginThrottleService struct {
+	server *server.Server
+}
+
+func NewLoginThrottleService(server *server.Server) *LoginThrottleService {
+	return &LoginThrottleService{server: server}
+}
+
+// Check returns an error and the time to wait when logins to the email or from the IP are blocked.
+// Unknown emails are throttled like existing ones, so the responses do not reveal which accounts exist.
+func (service *LoginThrottleService) Check(email, ip string) (time.Duration, error) {
+	ctx := context.Background()
+	keys := []string{
+		fmt.Sprintf(LoginBlockCacheKey, normalizeLoginEmail(email)),
+		fmt.Sprintf(LoginBlockIPCacheKey, ip),
+	}
+
+	var blocks []*redis.StringCmd
+	var ttls []*redis.DurationCmd
+	_, err := service.server.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			blocks = append(blocks, pipe.Get(ctx, key))
+			ttls = append(ttls, pipe.TTL(ctx, key))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, api.INTERNAL_SERVICE_ERROR("Failed to check login attempts")
+	}
+
+	var backoff time.Duration
+	for i, block := range blocks {
+		wait := ttls[i].Val()
+		if wait < time.Second {
+			wait = time.Second
+		}
+		switch block.Val() {
+		case LoginBlockLocked:
+			if i == 0 {
+				return wait, api.LOGIN_LOCKED("Too many failed logins for this account, try again later")
+			}
+			return wait, api.LOGIN_LOCKED("Too many failed logins from this address, try again later")
+		case LoginBlockBackoff:
+			if wait > backoff {
+				backoff = wait
+			}
+		}
+	}
+	if backoff > 0 {
+		return backoff, api.TOO_MANY_REQUESTS("Wait before trying to log in again")
+	}
+	return 0, nil
+}
+
+// RecordFailure counts a failed login of the email from the IP and reports whether either is now locked
+func (service *LoginThrottleService) RecordFailure(email, ip string) (bool, error) {
+	ctx := context.Background()
+	cfg := service.server.Config.Auth.Lockout
+	lockout := cfg.LockoutMinutes * 60
+	window := LoginFailureWindowHours * 60 * 60
+
+	accountDelay, err := recordFailureScript.Run(ctx, service.server.Redis, []string{
+		fmt.Sprintf(LoginFailuresCacheKey, normalizeLoginEmail(email)),
+		fmt.Sprintf(LoginBlockCacheKey, normalizeLoginEmail(email)),
+	}, cfg.MaxAttempts, lockout, cfg.BackoffSeconds, window).Int()
+	if err != nil {
+		return false, api.INTERNAL_SERVICE_ERROR("Failed to record login attempt")
+	}
+
+	// Addresses shared by many users only get locked, a backoff for everyone behind them would be too disruptive
+	ipDelay, err := recordFailureScript.Run(ctx, service.server.Redis, []string{
+		fmt.Sprintf(LoginFailuresIPCacheKey, ip),
+		fmt.Sprintf(LoginBlockIPCacheKey, ip),
+	}, cfg.IPMaxAttempts, lockout, 0, window).Int()
+	if err != nil {
+		return false, api.INTERNAL_SERVICE_ERROR("Failed to record login attempt")
+	}
+
+	return accountDelay < 0 || ipDelay < 0, nil
+}
+
+// RecordSuccess forgets the failed logins of the account, failures from the IP keep counting
+func (service *LoginThrottleService) RecordSuccess(email string) {
+	service.Unlock(email)
+}
+
+// Unlock lifts the lockout of an account before it expires
+func (service *LoginThrottleService) Unlock(email string) error {
+	err := service.server.Redis.Del(context.Background(),
+		fmt.Sprintf(LoginFailuresCacheKey, normalizeLoginEmail(email)),
+		fmt.Sprintf(LoginBlockCacheKey, normalizeLoginEmail(email))).Err()
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to unlock login")
+	}
+	return nil
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"goweb/api"
+	"goweb/models"
+	"goweb/server"
+	"goweb/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// MFAPendingCacheKey holds the user of a login waiting for its second factor
+	MFAPendingCacheKey = "mfa:pending:%s"
+	// MFAEnrollCacheKey holds a TOTP secret until the user confirms it with a first code
+	MFAEnrollCacheKey = "mfa:enroll:%d"
+	// MFALastStepCacheKey remembers the last accepted TOTP time step, so a code is accepted only once
+	MFALastStepCacheKey = "mfa:totp:%d:step"
+)
+
+const MFAPendingMinutes = 5
+const MFAEnrollMinutes = 10
+const MFAMaxAttempts = 5
+const RecoveryCodeCount = 10
+const TOTPIssuer = "Ohmex"
+
+// acceptStepScript stores the TOTP step only when it is newer than the last accepted one
+var acceptStepScript = redis.NewScript(`
+local last = tonumber(redis.call('GET', KEYS[1]) or '-1')
+if tonumber(ARGV[1]) <= last then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2])
+return 1
+`)
+
+// consumeAttemptScript counts a verification attempt of a pending login, dropping it after too many failures
+var consumeAttemptScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return nil
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if attempts > tonumber(ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+	return nil
+end
+return redis.call('HGET', KEYS[1], 'user_id')
+`)
+
+type MFAService struct {
+	server *server.Server
+}
+
+func NewMFAService(server *server.Server) *MFAService {
+	return &MFAService{server: server}
+}
+
+// BeginEnrollment generates a TOTP secret for the user, it is only activated by ConfirmEnrollment
+func (service *MFAService) BeginEnrollment(user *models.User) (secret, uri string, err error) {
+	if user.MFAEnabled {
+		return "", "", api.RESOURCE_EXISTS("Two-factor authentication is already enabled")
+	}
+
+	if secret, err = util.GenerateTOTPSecret(); err != nil {
+		return "", "", api.INTERNAL_SERVICE_ERROR("Failed to generate TOTP secret")
+	}
+
+	key := fmt.Sprintf(MFAEnrollCacheKey, user.ID)
+	if err = service.server.Redis.Set(context.Background(), key, secret, time.Minute*MFAEnrollMinutes).Err(); err != nil {
+		return "", "", api.INTERNAL_SERVICE_ERROR("Failed to store TOTP secret")
+	}
+
+	return secret, util.TOTPProvisioningURI(TOTPIssuer, user.Email, secret), nil
+}
+
+// ConfirmEnrollment enables MFA once the user proves the authenticator works, returning fresh recovery codes
+func (service *MFAService) ConfirmEnrollment(user *models.User, code string) ([]string, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf(MFAEnrollCacheKey, user.ID)
+
+	secret, err := service.server.Redis.Get(ctx, key).Result()
+	if err != nil {
+		return nil, api.RESOURCE_NOT_FOUND("No pending enrollment, start the enrollment again")
+	}
+
+	step, ok := util.ValidateTOTP(secret, code, time.Now())
+	if !ok || !service.acceptStep(user, step) {
+		return nil, api.INVALID_CREDENTIALS("Invalid verification code")
+	}
+
+	var codes []string
+	err = service.server.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{"mfa_enabled": true, "totp_secret": secret}).Error; err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, user)
+		return err
+	})
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to enable two-factor authentication")
+	}
+
+	user.MFAEnabled, user.TOTPSecret = true, secret
+	service.invalidateUser(user)
+	service.server.Redis.Del(ctx, key)
+	return codes, nil
+}
+
+// Disable turns MFA off after verifying a current code
+func (service *MFAService) Disable(user *models.User, code string) error {
+	if err := service.Verify(user, code); err != nil {
+		return err
+	}
+
+	err := service.server.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{"mfa_enabled": false, "totp_secret": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to disable two-factor authentication")
+	}
+
+	user.MFAEnabled, user.TOTPSecret = false, ""
+	service.invalidateUser(user)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a current code
+func (service *MFAService) RegenerateRecoveryCodes(user *models.User, code string) ([]string, error) {
+	if err := service.Verify(user, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(service.server.DB, user)
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to generate recovery codes")
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP code or, failing that, consumes a matching recovery code
+func (service *MFAService) Verify(user *models.User, code string) error {
+	if !user.MFAEnabled {
+		return api.FIELD_VALIDATION_ERROR("Two-factor authentication is not enabled")
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := util.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		if service.acceptStep(user, step) {
+			return nil
+		}
+		return api.INVALID_CREDENTIALS("Verification code was already used")
+	}
+
+	if service.useRecoveryCode(user, code) {
+		return nil
+	}
+	return api.INVALID_CREDENTIALS("Invalid verification code")
+}
+
+// CreatePendingLogin stores a short-lived login waiting for its second factor and returns its token
+func (service *MFAService) CreatePendingLogin(user *models.User) (token string, exp int64, err error) {
+	random := make([]byte, 32)
+	if _, err = rand.Read(random); err != nil {
+		return "", 0, err
+	}
+	token = hex.EncodeToString(random)
+
+	ctx := context.Background()
+	key := fmt.Sprintf(MFAPendingCacheKey, token)
+	_, err = service.server.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "user_id", user.ID, "attempts", 0)
+		pipe.Expire(ctx, key, time.Minute*MFAPendingMinutes)
+		return nil
+	})
+	return token, time.Now().Add(time.Minute * MFAPendingMinutes).Unix(), err
+}
+
+// PendingLoginUser returns the user of a pending login without counting an attempt
+func (service *MFAService) PendingLoginUser(token string) (*models.User, error) {
+	userID, err := service.server.Redis.HGet(context.Background(), fmt.Sprintf(MFAPendingCacheKey, token), "user_id").Uint64()
+	if err != nil {
+		return nil, api.INVALID_TOKEN("Invalid or expired MFA token")
+	}
+
+	user := new(models.User)
+	if err := service.server.DB.First(user, userID).Error; err != nil {
+		return nil, api.USER_NOT_FOUND()
+	}
+	return user, nil
+}
+
+// CompletePendingLogin verifies the code of a pending login and returns its user.
+// The pending token is single use and dropped after too many wrong codes.
+func (service *MFAService) CompletePendingLogin(token, code string) (*models.User, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf(MFAPendingCacheKey, token)
+
+	userID, err := consumeAttemptScript.Run(ctx, service.server.Redis, []string{key}, MFAMaxAttempts).Text()
+	if err != nil {
+		return nil, api.INVALID_TOKEN("Invalid or expired MFA token")
+	}
+	id, _ := strconv.ParseUint(userID, 10, 64)
+
+	user := new(models.User)
+	if err := service.server.DB.Preload("Domains").First(user, id).Error; err != nil {
+		return nil, api.USER_NOT_FOUND()
+	}
+
+	if err := service.Verify(user, code); err != nil {
+		return nil, err
+	}
+
+	service.server.Redis.Del(ctx, key)
+	return user, nil
+}
+
+// invalidateUser drops cached copies of the user, so the changed MFA state is picked up everywhere
+func (service *MFAService) invalidateUser(user *models.User) {
+	invalidateCachedUser(service.server, user)
+}
+
+func (service *MFAService) acceptStep(user *models.User, step int64) bool {
+	ttl := (util.TOTPSkew*2 + 1) * util.TOTPPeriod
+	accepted, err := acceptStepScript.Run(context.Background(), service.server.Redis,
+		[]string{fmt.Sprintf(MFALastStepCacheKey, user.ID)}, step, ttl).Int()
+	return err == nil && accepted == 1
+}
+
+func (service *MFAService) useRecoveryCode(user *models.User, code string) bool {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return false
+	}
+
+	var recoveryCodes []models.RecoveryCode
+	service.server.DB.Where("user_id = ? AND used_at IS NULL", user.ID).Find(&recoveryCodes)
+	for i := range recoveryCodes {
+		if util.CheckPasswordHash(recoveryCodes[i].CodeHash, code) != nil {
+			continue
+		}
+		// Conditional update, a code raced by two requests is only accepted once
+		result := service.server.DB.Model(&recoveryCodes[i]).Where("used_at IS NULL").Update("used_at", time.Now())
+		return result.Error == nil && result.RowsAffected == 1
+	}
+	return false
+}
+
+// replaceRecoveryCodes deletes the existing recovery codes of the user and stores new ones
+func replaceRecoveryCodes(tx *gorm.DB, user *models.User) ([]string, error) {
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		random := make([]byte, 5)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(random)
+		hash, err := util.HashPassword(code)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&models.RecoveryCode{UserID: user.ID, CodeHash: hash}).Error; err != nil {
+			return nil, err
+		}
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode strips the dash and case so codes can be typed loosely
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	if len(code) != 10 {
+		return ""
+	}
+	return code
+}
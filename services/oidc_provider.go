@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"goweb/config"
+	"goweb/keys"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// OIDCDiscoveryHours is how long discovered endpoints are used before the issuer is asked again
+const OIDCDiscoveryHours = 24
+
+// OIDCKeyRefreshMinutes limits how often an unknown key ID triggers a new fetch of the issuer's JWKS
+const OIDCKeyRefreshMinutes = 1
+
+// oidcSigningMethods are the algorithms accepted for ID tokens, symmetric ones would let anyone knowing the client secret sign tokens
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// OIDCDiscovery is the part of an issuer's openid-configuration used for logins
+type OIDCDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCClaims are the ID token and userinfo claims mapped onto users
+type OIDCClaims struct {
+	Email             string   `json:"email"`
+	EmailVerified     oidcBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Picture           string   `json:"picture"`
+	Nonce             string   `json:"nonce"`
+	AuthorizedParty   string   `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// oidcBool accepts booleans sent as JSON strings, as some issuers do for email_verified
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = oidcBool(v)
+	case string:
+		*b = oidcBool(strings.EqualFold(v, "true"))
+	}
+	return nil
+}
+
+// OIDCProvider is a configured OpenID Connect issuer.
+// Endpoints and signing keys are discovered on first use and cached, so an unreachable issuer does not keep the service from starting.
+type OIDCProvider struct {
+	config config.OIDCConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *OIDCDiscovery
+	discoveredAt  time.Time
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOIDCProvider(cfg config.OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{
+		config: cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name is the path segment the provider is served under
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// Discover returns the issuer's endpoints, fetching them when not cached
+func (p *OIDCProvider) Discover(ctx context.Context) (*OIDCDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil && time.Since(p.discoveredAt) < time.Hour*OIDCDiscoveryHours {
+		return p.discovery, nil
+	}
+
+	discovery := new(OIDCDiscovery)
+	url := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.fetchJSON(ctx, url, discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover issuer %s: %w", p.config.Issuer, err)
+	}
+	// The issuer must identify itself exactly as configured, otherwise tokens of another issuer could be accepted
+	if discovery.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("issuer %s announces itself as %s", p.config.Issuer, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("issuer %s misses required endpoints", p.config.Issuer)
+	}
+
+	p.discovery = discovery
+	p.discoveredAt = time.Now()
+	return discovery, nil
+}
+
+// OAuthConfig returns the OAuth2 configuration with the discovered endpoints
+func (p *OIDCProvider) OAuthConfig(ctx context.Context) (*oauth2.Config, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}, nil
+}
+
+// VerifyIDToken checks the signature of an ID token against the issuer's JWKS, its issuer, audience,
+// expiry and the nonce sent with the login
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*OIDCClaims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := new(OIDCClaims)
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, discovery.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("invalid ID token: nonce does not match the login")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("invalid ID token: issued to %s", claims.AuthorizedParty)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid ID token: missing subject")
+	}
+	return claims, nil
+}
+
+// Userinfo fetches the claims of the userinfo endpoint, for issuers not putting the email into the ID token
+func (p *OIDCProvider) Userinfo(ctx context.Context, token *oauth2.Token) (*OIDCClaims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if discovery.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s has no userinfo endpoint", p.config.Issuer)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	token.SetAuthHeader(req)
+	claims := new(OIDCClaims)
+	if err := p.doJSON(req, claims); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+	return claims, nil
+}
+
+// signingKey looks up a key of the issuer's JWKS, fetching the set again when the key is unknown
+func (p *OIDCProvider) signingKey(ctx context.Context, jwksURI, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < time.Minute*OIDCKeyRefreshMinutes {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	set := new(keys.JWKSet)
+	if err := p.fetchJSON(ctx, jwksURI, set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	p.keys = make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys this service cannot use are skipped, the issuer may publish more than ID tokens need
+		if key, err := jwk.PublicKey(); err == nil {
+			p.keys[jwk.Kid] = key
+		}
+	}
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key by ID, tokens without key ID are accepted when the issuer publishes a single key
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *OIDCProvider) fetchJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return p.doJSON(req, target)
+}
+
+func (p *OIDCProvider) doJSON(req *http.Request, target interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", req.URL.Host, resp.StatusCode)
+	}
+	return json.Unmarshal(body, target)
+}
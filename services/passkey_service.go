@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goweb/api"
+	"goweb/models"
+	"goweb/server"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// PasskeyRegistrationCacheKey holds the session data of a registration ceremony until it is finished
+	PasskeyRegistrationCacheKey = "webauthn:registration:%s"
+	// PasskeyLoginCacheKey holds the session data of a login ceremony until it is finished
+	PasskeyLoginCacheKey = "webauthn:login:%s"
+)
+
+const PasskeyCeremonyMinutes = 5
+
+type PasskeyService struct {
+	server   *server.Server
+	webAuthn *webauthn.WebAuthn
+}
+
+func NewPasskeyService(server *server.Server) *PasskeyService {
+	cfg := server.Config.Auth.WebAuthn
+	origins := cfg.RPOrigins
+	if len(origins) == 0 && server.Config.HTTP.FrontendURL != "" {
+		origins = []string{server.Config.HTTP.FrontendURL}
+	}
+	rpID := cfg.RPID
+	if rpID == "" && len(origins) > 0 {
+		if origin, err := url.Parse(origins[0]); err == nil {
+			rpID = origin.Hostname()
+		}
+	}
+	displayName := cfg.RPDisplayName
+	if displayName == "" {
+		displayName = TOTPIssuer
+	}
+
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: displayName,
+		RPOrigins:     origins,
+	})
+	if err != nil {
+		log.Warn().Str("event", "passkeys_disabled").Err(err).Msg("Passkeys are not configured")
+	}
+	return &PasskeyService{server: server, webAuthn: webAuthn}
+}
+
+// passkeyUser adapts a user and its credentials to the webauthn.User interface.
+// The user handle is the user UUID, it does not reveal the email to authenticators.
+type passkeyUser struct {
+	user        *models.User
+	credentials []models.WebAuthnCredential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	id := u.user.UUID
+	return id[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.user.Name != "" {
+		return u.user.Name
+	}
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for i := range u.credentials {
+		credentials = append(credentials, toWebAuthnCredential(&u.credentials[i]))
+	}
+	return credentials
+}
+
+// BeginRegistration starts registering a passkey for the user, returning the options for navigator.credentials.create
+func (service *PasskeyService) BeginRegistration(user *models.User) (creation *protocol.CredentialCreation, token string, exp int64, err error) {
+	if service.webAuthn == nil {
+		return nil, "", 0, api.INTERNAL_SERVICE_ERROR("Passkeys are not configured")
+	}
+
+	owner, err := service.loadPasskeyUser(user)
+	if err != nil {
+		return nil, "", 0, api.INTERNAL_SERVICE_ERROR("Failed to fetch passkeys")
+	}
+
+	// Passkeys are discoverable credentials, so login works without entering an email
+	creation, session, err := service.webAuthn.BeginRegistration(owner,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(owner.WebAuthnCredentials()).CredentialDescriptors()))
+	if err != nil {
+		return nil, "", 0, api.INTERNAL_SERVICE_ERROR("Failed to start passkey registration")
+	}
+
+	if token, exp, err = service.storeSession(PasskeyRegistrationCacheKey, session); err != nil {
+		return nil, "", 0, api.INTERNAL_SERVICE_ERROR("Failed to start passkey registration")
+	}
+	return creation, token, exp, nil
+}
+
+// FinishRegistration verifies the attestation of the authenticator and stores the new passkey
+func (service *PasskeyService) FinishRegistration(user *models.User, token, name string, response []byte) (*models.WebAuthnCredential, error) {
+	if service.webAuthn == nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Passkeys are not configured")
+	}
+
+	session, err := service.loadSession(PasskeyRegistrationCacheKey, token)
+	if err != nil {
+		return nil, api.INVALID_TOKEN("Invalid or expired passkey session")
+	}
+
+	owner, err := service.loadPasskeyUser(user)
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to fetch passkeys")
+	}
+	// The ceremony must be finished by the user who started it
+	if string(session.UserID) != string(owner.WebAuthnID()) {
+		return nil, api.INVALID_TOKEN("Invalid or expired passkey session")
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, api.FIELD_VALIDATION_ERROR("Invalid passkey credential")
+	}
+	credential, err := service.webAuthn.CreateCredential(owner, *session, parsed)
+	if err != nil {
+		return nil, api.INVALID_CREDENTIALS("Passkey verification failed: " + protocolDetails(err))
+	}
+
+	if name == "" {
+		name = "Passkey"
+	}
+	model := &models.WebAuthnCredential{
+		UserID:          user.ID,
+		Name:            name,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(credential.ID),
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      joinTransports(credential.Transport),
+		AAGUID:          credential.Authenticator.AAGUID,
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		SignCount:       credential.Authenticator.SignCount,
+	}
+	if err := service.server.DB.Create(model).Error; err != nil {
+		return nil, api.RESOURCE_CREATION_FAILED("Failed to store passkey")
+	}
+	return model, nil
+}
+
+// BeginLogin starts a login with any passkey of this relying party, returning the options for navigator.credentials.get
+func (service *PasskeyService) BeginLogin() (assertion *protocol.CredentialAssertion, token string, exp int64, err error) {
+	if service.webAuthn == nil {
+		return nil, "", 0, api.INTERNAL_SERVICE_ERROR("Passkeys are not configured")
+	}
+
+	assertion, session, err := service.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationPreferred))
+	if err != nil {
+		return nil, "", 0, api.INTERNAL_SERVICE_ERROR("Failed to start passkey login")
+	}
+
+	if token, exp, err = service.storeSession(PasskeyLoginCacheKey, session); err != nil {
+		return nil, "", 0, api.INTERNAL_SERVICE_ERROR("Failed to start passkey login")
+	}
+	return assertion, token, exp, nil
+}
+
+// FinishLogin verifies the assertion and returns the user the passkey belongs to.
+// userVerified reports whether the authenticator verified the user with a PIN or biometrics.
+func (service *PasskeyService) FinishLogin(token string, response []byte) (user *models.User, userVerified bool, err error) {
+	if service.webAuthn == nil {
+		return nil, false, api.INTERNAL_SERVICE_ERROR("Passkeys are not configured")
+	}
+
+	session, err := service.loadSession(PasskeyLoginCacheKey, token)
+	if err != nil {
+		return nil, false, api.INVALID_TOKEN("Invalid or expired passkey session")
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, false, api.FIELD_VALIDATION_ERROR("Invalid passkey credential")
+	}
+
+	var owner *passkeyUser
+	resolveUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		id, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		found := new(models.User)
+		if err := service.server.DB.Preload("Domains").Where("uuid = ?", id.String()).First(found).Error; err != nil {
+			return nil, err
+		}
+		if owner, err = service.loadPasskeyUser(found); err != nil {
+			return nil, err
+		}
+		return owner, nil
+	}
+
+	_, credential, err := service.webAuthn.ValidatePasskeyLogin(resolveUser, *session, parsed)
+	if err != nil || owner == nil {
+		return nil, false, api.INVALID_CREDENTIALS("Passkey verification failed: " + protocolDetails(err))
+	}
+
+	model := owner.findCredential(credential.ID)
+	if model == nil {
+		return nil, false, api.INVALID_CREDENTIALS("Unknown passkey")
+	}
+
+	// A signature counter that did not increase means the private key may exist more than once
+	if credential.Authenticator.CloneWarning {
+		log.Warn().Str("event", "passkey_clone_warning").Uint64("user_id", owner.user.ID).Str("passkey", model.UUID.String()).
+			Uint32("stored_count", model.SignCount).Uint32("presented_count", credential.Authenticator.SignCount).
+			Msg("Passkey rejected, the authenticator may be cloned")
+		return nil, false, api.INVALID_CREDENTIALS("Passkey rejected, the authenticator may be cloned")
+	}
+
+	now := time.Now()
+	err = service.server.DB.Model(model).Updates(map[string]interface{}{
+		"sign_count":   credential.Authenticator.SignCount,
+		"flags":        uint8(credential.Flags.ProtocolValue()),
+		"last_used_at": now,
+	}).Error
+	if err != nil {
+		return nil, false, api.INTERNAL_SERVICE_ERROR("Failed to update passkey")
+	}
+
+	return owner.user, credential.Flags.UserVerified, nil
+}
+
+// ListForUser returns the passkeys of the user, most recent first
+func (service *PasskeyService) ListForUser(userID uint64) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	err := service.server.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&credentials).Error
+	return credentials, err
+}
+
+// Delete removes a passkey of the user, it can no longer be used to log in.
+// The last passkey of a user without a password or linked account is kept, the account would be locked out otherwise.
+func (service *PasskeyService) Delete(userID uint64, id string) error {
+	return service.server.DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the user serializes the deletion with unlinking identities, see IdentityService.Unlink
+		user := new(models.User)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(user, userID).Error; err != nil {
+			return api.USER_NOT_FOUND()
+		}
+		credential := new(models.WebAuthnCredential)
+		if err := tx.Where("uuid = ? AND user_id = ?", id, userID).First(credential).Error; err != nil {
+			return api.RESOURCE_NOT_FOUND("Passkey not found")
+		}
+
+		var identities, passkeys int64
+		tx.Model(&models.Identity{}).Where("user_id = ?", userID).Count(&identities)
+		tx.Model(&models.WebAuthnCredential{}).Where("user_id = ? AND id <> ?", userID, credential.ID).Count(&passkeys)
+		if user.Password == "" && identities == 0 && passkeys == 0 {
+			return api.LAST_LOGIN_METHOD("Set a password or link an account before deleting your last passkey")
+		}
+
+		if err := tx.Delete(credential).Error; err != nil {
+			return api.INTERNAL_SERVICE_ERROR("Failed to delete passkey")
+		}
+		return nil
+	})
+}
+
+func (service *PasskeyService) loadPasskeyUser(user *models.User) (*passkeyUser, error) {
+	credentials, err := service.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+// storeSession keeps the ceremony session data in Redis and returns the token referencing it
+func (service *PasskeyService) storeSession(keyFormat string, session *webauthn.SessionData) (token string, exp int64, err error) {
+	random := make([]byte, 32)
+	if _, err = rand.Read(random); err != nil {
+		return "", 0, err
+	}
+	token = hex.EncodeToString(random)
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", 0, err
+	}
+	key := fmt.Sprintf(keyFormat, token)
+	err = service.server.Redis.Set(context.Background(), key, data, time.Minute*PasskeyCeremonyMinutes).Err()
+	return token, time.Now().Add(time.Minute * PasskeyCeremonyMinutes).Unix(), err
+}
+
+// loadSession returns the ceremony session data and drops it, every challenge is answered only once
+func (service *PasskeyService) loadSession(keyFormat, token string) (*webauthn.SessionData, error) {
+	if token == "" {
+		return nil, errors.New("missing passkey session")
+	}
+	data, err := service.server.Redis.GetDel(context.Background(), fmt.Sprintf(keyFormat, token)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	session := new(webauthn.SessionData)
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (u *passkeyUser) findCredential(id []byte) *models.WebAuthnCredential {
+	encoded := base64.RawURLEncoding.EncodeToString(id)
+	for i := range u.credentials {
+		if u.credentials[i].CredentialID == encoded {
+			return &u.credentials[i]
+		}
+	}
+	return nil
+}
+
+func toWebAuthnCredential(model *models.WebAuthnCredential) webauthn.Credential {
+	id, _ := base64.RawURLEncoding.DecodeString(model.CredentialID)
+	var transports []protocol.AuthenticatorTransport
+	for _, transport := range strings.Split(model.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              id,
+		PublicKey:       model.PublicKey,
+		AttestationType: model.AttestationType,
+		Transport:       transports,
+		Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(model.Flags)),
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    model.AAGUID,
+			SignCount: model.SignCount,
+		},
+	}
+}
+
+func joinTransports(transports []protocol.AuthenticatorTransport) string {
+	names := make([]string, len(transports))
+	for i, transport := range transports {
+		names[i] = string(transport)
+	}
+	return strings.Join(names, ",")
+}
+
+// protocolDetails returns the reason a ceremony failed, without the developer information of the library
+func protocolDetails(err error) string {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.Details != "" {
+		return protocolErr.Details
+	}
+	return "invalid credential"
+}
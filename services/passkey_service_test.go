@@ -0,0 +1,53 @@
+package services
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/server"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newPasskeyTestService(t *testing.T) (*PasskeyService, *IdentityService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Domain{}, &models.User{}, &models.Identity{}, &models.WebAuthnCredential{}); err != nil {
+		t.Fatal(err)
+	}
+	s := &server.Server{DB: db}
+	return &PasskeyService{server: s}, &IdentityService{server: s}, db
+}
+
+// A passwordless user who unlinked their social account because of a passkey must not be able to delete that passkey
+func TestDeleteLastPasskeyOfPasswordlessUser(t *testing.T) {
+	passkeys, identities, db := newPasskeyTestService(t)
+	user := &models.User{Name: "Social", Email: "social@example.com"}
+	db.Create(user)
+	identity := &models.Identity{UserID: user.ID, Provider: "google", Subject: "1"}
+	db.Create(identity)
+	passkey := &models.WebAuthnCredential{UserID: user.ID, CredentialID: "passkey"}
+	db.Create(passkey)
+
+	if _, err := identities.Unlink(user.ID, identity.UUID.String()); err != nil {
+		t.Fatalf("unlink with a passkey left: %v", err)
+	}
+	err := passkeys.Delete(user.ID, passkey.UUID.String())
+	if res, ok := err.(api.Response); !ok || res.Code != api.CodeLastLoginMethod {
+		t.Fatalf("expected the last passkey to be kept, got %v", err)
+	}
+
+	other := &models.WebAuthnCredential{UserID: user.ID, CredentialID: "other"}
+	db.Create(other)
+	if err := passkeys.Delete(user.ID, passkey.UUID.String()); err != nil {
+		t.Fatalf("delete with another passkey left: %v", err)
+	}
+	if err := passkeys.Delete(user.ID, passkey.UUID.String()); err == nil {
+		t.Fatal("deleted passkey found again")
+	}
+}
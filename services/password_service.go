@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"goweb/api"
+	"goweb/mailer"
+	"goweb/models"
+	"goweb/server"
+	"goweb/util"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// PasswordResetCacheKey maps the hash of a reset token to its user
+	PasswordResetCacheKey = "password:reset:%s"
+	// PasswordResetUserCacheKey holds the hash of the latest reset token of a user, requesting a new one invalidates the previous
+	PasswordResetUserCacheKey = "password:reset:user:%d"
+)
+
+const PasswordResetMinutes = 30
+
+// consumeResetScript returns the user of a reset token and deletes it, so it is accepted once
+var consumeResetScript = redis.NewScript(`
+local userID = redis.call('GET', KEYS[1])
+if not userID then
+	return nil
+end
+redis.call('DEL', KEYS[1])
+local userKey = string.format(ARGV[1], userID)
+if redis.call('GET', userKey) ~= ARGV[2] then
+	return nil
+end
+redis.call('DEL', userKey)
+return userID
+`)
+
+type PasswordService struct {
+	server       *server.Server
+	userService  *UserService
+	tokenService *TokenService
+}
+
+func NewPasswordService(server *server.Server) *PasswordService {
+	return &PasswordService{
+		server:       server,
+		userService:  NewUserService(server.DB),
+		tokenService: NewTokenService(server),
+	}
+}
+
+// RequestReset mails a reset link to the user with the given email.
+// Unknown emails are not reported, so the endpoint does not reveal which accounts exist.
+func (service *PasswordService) RequestReset(email string) error {
+	user := new(models.User)
+	if err := service.userService.GetUserByEmail(user, email); err != nil || user.ID == 0 {
+		return nil
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to generate reset token")
+	}
+	token := hex.EncodeToString(random)
+	hash := hashResetToken(token)
+
+	ctx := context.Background()
+	ttl := time.Minute * PasswordResetMinutes
+	userKey := fmt.Sprintf(PasswordResetUserCacheKey, user.ID)
+	_, err := service.server.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf(PasswordResetCacheKey, hash), user.ID, ttl)
+		pipe.Set(ctx, userKey, hash, ttl)
+		return nil
+	})
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to store reset token")
+	}
+
+	link := service.server.Config.HTTP.FrontendURL + "/password/reset?token=" + url.QueryEscape(token)
+	err = service.server.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nuse the link below to choose a new password, it is valid for %d minutes:\n\n%s\n\n"+
+			"If you did not request a password reset, you can ignore this email.\n", user.Name, PasswordResetMinutes, link),
+	})
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to send reset email")
+	}
+	return nil
+}
+
+// ResetPassword sets the new password of the token's user and logs the user out on all devices
+func (service *PasswordService) ResetPassword(token, password string) (*models.User, error) {
+	hash := hashResetToken(token)
+	userID, err := consumeResetScript.Run(context.Background(), service.server.Redis,
+		[]string{fmt.Sprintf(PasswordResetCacheKey, hash)}, PasswordResetUserCacheKey, hash).Text()
+	if err != nil {
+		return nil, api.INVALID_TOKEN("Invalid or expired reset token")
+	}
+	id, _ := strconv.ParseUint(userID, 10, 64)
+
+	user := new(models.User)
+	if err := service.server.DB.First(user, id).Error; err != nil {
+		return nil, api.USER_NOT_FOUND()
+	}
+
+	hashed, err := util.HashPassword(password)
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to hash password")
+	}
+	if err := service.server.DB.Model(user).Update("password", hashed).Error; err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to update password")
+	}
+	invalidateCachedUser(service.server, user)
+	// Proving access to the email is as good as an admin unlock
+	NewLoginThrottleService(service.server).Unlock(user.Email)
+
+	if err := service.tokenService.RevokeAllSessions(user.ID); err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Password changed, but failed to revoke sessions")
+	}
+	return user, nil
+}
+
+// hashResetToken is the form reset tokens are stored in, a leaked Redis dump does not contain usable tokens
+func hashResetToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
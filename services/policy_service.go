@@ -0,0 +1,421 @@
+package services
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/requests"
+	"goweb/server"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// PolicyService manages the Casbin policies (role, domain, object, action) of a domain.
+// Changes go through the server's enforcer, which saves them with the gorm adapter.
+type PolicyService struct {
+	server *server.Server
+}
+
+func NewPolicyService(server *server.Server) *PolicyService {
+	return &PolicyService{server: server}
+}
+
+// List returns the policies and conditional policies of the domain sorted by role, object and action, optionally
+// only those of one role. Roles the domain defines no policies for are listed with the template policies of the
+// System domain.
+func (service *PolicyService) List(domain *models.Domain, role string) ([][]string, error) {
+	roleService := NewRoleService(service.server)
+	policies, err := roleService.policiesIn(role, domain.UUID.String())
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	if system := roleService.systemDomain(); system.ID != 0 && system.ID != domain.ID {
+		templates, err := roleService.policiesIn(role, system.UUID.String())
+		if err != nil {
+			return nil, api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+		}
+		local := map[string]bool{}
+		for _, policy := range policies {
+			local[policy[0]] = true
+		}
+		for _, template := range templates {
+			if !local[template[0]] {
+				policies = append(policies, template)
+			}
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return strings.Join(policies[i], "\x00") < strings.Join(policies[j], "\x00")
+	})
+	return policies, nil
+}
+
+// Add grants the action on the object to a role of the domain, under the request's condition if it names one.
+// The subject can only grant permissions it holds itself, so the endpoint cannot be used to escalate its own
+// rights, a conditional permission is held by holding it on the subject's own resources.
+// A role inheriting the System domain's template is overridden in the domain by a copy of it first.
+func (service *PolicyService) Add(subject string, domain *models.Domain, request *requests.PermissionRequest) error {
+	if !NewRoleService(service.server).RoleExistsInDomain(request.Role, domain) {
+		return api.RESOURCE_NOT_FOUND("Role not found")
+	}
+	owner := ""
+	if request.Condition != "" {
+		owner = subject
+	}
+	held, err := service.server.Authorize(subject, domain.UUID.String(), request.Object, request.Action, owner)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Casbin enforcement error")
+	}
+	if !held {
+		return api.CASBIN_UNAUTHORIZED("You can only grant permissions you hold")
+	}
+	// A permission the role has already, maybe through its template, must not fork the template
+	roleService := NewRoleService(service.server)
+	policies, err := roleService.RolePolicies(request.Role, domain)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	if hasPermission(policies, request) {
+		return api.RESOURCE_EXISTS("Permission already exists")
+	}
+	forked, err := override(service.server.Casbin, request.Role, domain, roleService.systemDomain())
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to save permission")
+	}
+
+	ptype, rule := permissionRule(request, domain)
+	added, err := service.server.Casbin.AddNamedPolicy(ptype, rule...)
+	if err != nil || !added {
+		if forked {
+			service.undoOverride(request.Role, domain)
+		}
+		if err != nil {
+			return api.INTERNAL_SERVICE_ERROR("Failed to save permission")
+		}
+		return api.RESOURCE_EXISTS("Permission already exists")
+	}
+	return nil
+}
+
+// Remove revokes the action on the object from a role of the domain, like Add overriding an inherited template
+func (service *PolicyService) Remove(domain *models.Domain, request *requests.PermissionRequest) error {
+	roleService := NewRoleService(service.server)
+	policies, err := roleService.RolePolicies(request.Role, domain)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	if !hasPermission(policies, request) {
+		return api.RESOURCE_NOT_FOUND("Permission not found")
+	}
+	system := roleService.systemDomain()
+	if len(policies) == 1 && system.ID != 0 && system.ID != domain.ID {
+		if templates, _ := roleService.policiesIn(request.Role, system.UUID.String()); len(templates) > 0 {
+			return api.TEMPLATE_OVERRIDE()
+		}
+	}
+	forked, err := override(service.server.Casbin, request.Role, domain, system)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to remove permission")
+	}
+
+	ptype, rule := permissionRule(request, domain)
+	removed, err := service.server.Casbin.RemoveNamedPolicy(ptype, rule...)
+	if err != nil || !removed {
+		if forked {
+			service.undoOverride(request.Role, domain)
+		}
+		if err != nil {
+			return api.INTERNAL_SERVICE_ERROR("Failed to remove permission")
+		}
+		return api.RESOURCE_NOT_FOUND("Permission not found")
+	}
+	return nil
+}
+
+// undoOverride drops the copy of the template override made for a change that did not happen, the role
+// inherits the template again
+func (service *PolicyService) undoOverride(role string, domain *models.Domain) {
+	for _, ptype := range policyTypes {
+		if _, err := service.server.Casbin.RemoveFilteredNamedPolicy(ptype, 0, role, domain.UUID.String()); err != nil {
+			log.Error().Str("event", "template_override_undo_failed").Err(err).Str("role", role).Str("domain", domain.UUID.String()).
+				Msg("Failed to drop the copy of a role template")
+		}
+	}
+}
+
+// ResetOverride removes the policies of the role in the domain, so it inherits the System domain's template again
+func (service *PolicyService) ResetOverride(domain *models.Domain, role string) error {
+	roleService := NewRoleService(service.server)
+	system := roleService.systemDomain()
+	if system.ID == 0 || system.ID == domain.ID {
+		return api.RESOURCE_NOT_FOUND("Role does not override a template")
+	}
+	templates, err := roleService.policiesIn(role, system.UUID.String())
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	if len(templates) == 0 {
+		return api.RESOURCE_NOT_FOUND("Role does not override a template")
+	}
+	removed, err := service.server.Casbin.RemoveFilteredPolicy(0, role, domain.UUID.String())
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to remove permissions")
+	}
+	removedConditional, err := service.server.Casbin.RemoveFilteredNamedPolicy(server.ConditionalPolicy, 0, role, domain.UUID.String())
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to remove permissions")
+	}
+	if !removed && !removedConditional {
+		return api.RESOURCE_NOT_FOUND("Role does not override a template")
+	}
+	return nil
+}
+
+// Effective returns the permissions the subject has in the domain through its roles in effect, including
+// inherited roles and the System domain's templates
+func (service *PolicyService) Effective(subject string, domain *models.Domain) ([][]string, error) {
+	roles, err := server.ActiveRoles(service.server.Casbin, service.server.RoleGrants, subject, domain.UUID.String())
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to resolve permissions")
+	}
+	roleService := NewRoleService(service.server)
+	var policies [][]string
+	for _, role := range roles {
+		rolePolicies, err := roleService.RolePolicies(role, domain)
+		if err != nil {
+			return nil, api.INTERNAL_SERVICE_ERROR("Failed to resolve permissions")
+		}
+		policies = append(policies, rolePolicies...)
+	}
+	return policies, nil
+}
+
+// Check decides each check for the subject in the domain, in the order given. Checks naming an owner
+// are decided by the conditional policies as well.
+func (service *PolicyService) Check(subject string, domain *models.Domain, checks []requests.PermissionCheck) ([]bool, error) {
+	allowed := make([]bool, 0, len(checks))
+	for _, check := range checks {
+		ok, err := service.server.Authorize(subject, domain.UUID.String(), check.Resource, check.Action, check.Owner)
+		if err != nil {
+			return nil, api.INTERNAL_SERVICE_ERROR("Casbin enforcement error")
+		}
+		allowed = append(allowed, ok)
+	}
+	return allowed, nil
+}
+
+// Explanation is a decision of the enforcer together with what led to it
+type Explanation struct {
+	Allowed bool
+	Rule    []string // policy the decision is based on, empty if none matched
+	Chain   []string // subject followed by the roles leading to the rule's role
+	Roles   []string // every role the subject has in the domain
+}
+
+// ResolveSubject accepts a user or API key UUID as is and looks up the UUID of a user by email. Only subjects
+// holding a role or role grant in the domain are resolved, others are not found like unknown ones.
+func (service *PolicyService) ResolveSubject(subject string, domain *models.Domain) (string, error) {
+	if _, err := uuid.Parse(subject); err != nil {
+		var user models.User
+		if err := service.server.DB.Where("email = ?", subject).First(&user).Error; err != nil {
+			return "", api.RESOURCE_NOT_FOUND("Subject not found")
+		}
+		subject = user.UUID.String()
+	}
+
+	roles, err := service.server.Casbin.GetFilteredGroupingPolicy(0, subject, "", domain.UUID.String())
+	if err != nil {
+		return "", api.INTERNAL_SERVICE_ERROR("Failed to resolve subject")
+	}
+	if len(roles) == 0 {
+		var grants int64
+		if err := service.server.DB.Model(&models.RoleGrant{}).Where("subject = ? AND domain = ?", subject, domain.UUID.String()).
+			Count(&grants).Error; err != nil {
+			return "", api.INTERNAL_SERVICE_ERROR("Failed to resolve subject")
+		}
+		if grants == 0 {
+			return "", api.RESOURCE_NOT_FOUND("Subject not found")
+		}
+	}
+	return subject, nil
+}
+
+// Explain decides whether the subject may perform the action on the object in the domain and reports the
+// matching rule and role chain. Like server.Authorize, conditional policies decide on the resource's owner
+// unless an unconditional one matches. With proposed changes the decision is made by a copy of the enforcer
+// they are applied to, nothing is saved.
+func (service *PolicyService) Explain(subject string, domain *models.Domain, object, action, owner string, whatIf *requests.WhatIfRequest) (*Explanation, error) {
+	var enforcer casbin.IEnforcer = service.server.Casbin
+	dom := domain.UUID.String()
+	if whatIf != nil {
+		sandbox, err := service.sandbox(subject, domain, whatIf)
+		if err != nil {
+			log.Error().Str("event", "casbin_sandbox_failed").Err(err).Msg("Failed to copy Casbin policies")
+			return nil, api.INTERNAL_SERVICE_ERROR("Failed to evaluate proposed policies")
+		}
+		enforcer = sandbox
+	}
+
+	allowed, rule, err := enforcer.EnforceEx(subject, dom, object, action)
+	if err == nil && !allowed {
+		allowed, rule, err = enforcer.EnforceEx(casbin.NewEnforceContext("2"), subject, dom, object, action, owner)
+	}
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Casbin enforcement error")
+	}
+	roles, err := server.ActiveRoles(enforcer, service.server.RoleGrants, subject, dom)
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to resolve roles")
+	}
+	sort.Strings(roles)
+
+	explanation := &Explanation{Allowed: allowed, Rule: rule, Chain: []string{subject}, Roles: roles}
+	if len(rule) > 0 {
+		explanation.Chain = roleChain(enforcer, subject, rule[0], dom)
+	}
+	return explanation, nil
+}
+
+// sandbox copies the enforcer's model and rules into an enforcer without adapter and applies the proposed changes
+func (service *PolicyService) sandbox(subject string, dom *models.Domain, whatIf *requests.WhatIfRequest) (*casbin.Enforcer, error) {
+	// The copy is taken under the enforcer's lock, a reload could replace the rules halfway otherwise
+	lock := service.server.Casbin.GetLock()
+	lock.RLock()
+	rules := service.server.Casbin.GetModel().Copy()
+	lock.RUnlock()
+	sandbox, err := casbin.NewEnforcer(rules)
+	if err != nil {
+		return nil, err
+	}
+	server.ConfigureEnforcer(sandbox, service.server.DB, service.server.RoleGrants)
+	if err := sandbox.BuildRoleLinks(); err != nil {
+		return nil, err
+	}
+
+	// Proposed policies override inherited templates like saved ones
+	domain := dom.UUID.String()
+	system := NewRoleService(service.server).systemDomain()
+	for _, policies := range [][]requests.PermissionRequest{whatIf.RemovePolicies, whatIf.AddPolicies} {
+		for _, policy := range policies {
+			if _, err := override(sandbox, policy.Role, dom, system); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, policy := range whatIf.RemovePolicies {
+		ptype, rule := permissionRule(&policy, dom)
+		if _, err := sandbox.RemoveNamedPolicy(ptype, rule...); err != nil {
+			return nil, err
+		}
+	}
+	for _, policy := range whatIf.AddPolicies {
+		ptype, rule := permissionRule(&policy, dom)
+		if _, err := sandbox.AddNamedPolicy(ptype, rule...); err != nil {
+			return nil, err
+		}
+	}
+	for _, role := range whatIf.RemoveRoles {
+		if _, err := sandbox.DeleteRoleForUserInDomain(subject, role, domain); err != nil {
+			return nil, err
+		}
+	}
+	for _, role := range whatIf.AddRoles {
+		if _, err := sandbox.AddRoleForUserInDomain(subject, role, domain); err != nil {
+			return nil, err
+		}
+	}
+	return sandbox, nil
+}
+
+// roleChain finds the shortest path of role assignments from the subject to the role in the domain
+func roleChain(enforcer casbin.IEnforcer, subject, role, domain string) []string {
+	previous := map[string]string{subject: ""}
+	queue := []string{subject}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == role {
+			chain := []string{}
+			for name := current; name != ""; name = previous[name] {
+				chain = append([]string{name}, chain...)
+			}
+			return chain
+		}
+		next, _ := enforcer.GetRolesForUser(current, domain)
+		for _, name := range next {
+			if _, seen := previous[name]; !seen {
+				previous[name] = current
+				queue = append(queue, name)
+			}
+		}
+	}
+	return []string{subject}
+}
+
+// override copies the template policies of the role from the System domain into the domain, unless the
+// domain defines policies of the role already. Changing a role's policies in a domain replaces the template
+// there, the copy keeps the permissions the role inherited until then. It reports whether it copied the template.
+func override(enforcer casbin.IEnforcer, role string, domain, system *models.Domain) (bool, error) {
+	if system.ID == 0 || system.ID == domain.ID {
+		return false, nil
+	}
+	for _, ptype := range policyTypes {
+		local, err := enforcer.GetFilteredNamedPolicy(ptype, 0, role, domain.UUID.String())
+		if err != nil || len(local) > 0 {
+			return false, err
+		}
+	}
+
+	forked := false
+	for _, ptype := range policyTypes {
+		templates, err := enforcer.GetFilteredNamedPolicy(ptype, 0, role, system.UUID.String())
+		if err != nil {
+			return false, err
+		}
+		if len(templates) == 0 {
+			continue
+		}
+		rules := make([][]string, 0, len(templates))
+		for _, template := range templates {
+			rule := append([]string{}, template...)
+			rule[1] = domain.UUID.String()
+			rules = append(rules, rule)
+		}
+		if _, err := enforcer.AddNamedPolicies(ptype, rules); err != nil {
+			return false, err
+		}
+		forked = true
+	}
+	return forked, nil
+}
+
+// permissionRule returns the policy type and the fields of the policy the request grants in the domain
+func permissionRule(request *requests.PermissionRequest, domain *models.Domain) (string, []interface{}) {
+	rule := []interface{}{request.Role, domain.UUID.String(), request.Object, request.Action}
+	if request.Condition != "" {
+		return server.ConditionalPolicy, append(rule, request.Condition)
+	}
+	return "p", rule
+}
+
+// hasPermission reports whether the policies include the permission of the request
+func hasPermission(policies [][]string, request *requests.PermissionRequest) bool {
+	for _, policy := range policies {
+		if policy[2] == request.Object && policy[3] == request.Action && policyCondition(policy) == request.Condition {
+			return true
+		}
+	}
+	return false
+}
+
+// policyCondition returns the condition of a conditional policy, empty for unconditional ones
+func policyCondition(policy []string) string {
+	if len(policy) > 4 {
+		return policy[4]
+	}
+	return ""
+}
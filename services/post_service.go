@@ -52,7 +52,8 @@ func (postService *PostService) Delete(post *models.Post) {
 func (postService *PostService) Update(post *models.Post, updatePostRequest *requests.UpdatePostRequest) {
 	post.Content = updatePostRequest.Content
 	post.Title = updatePostRequest.Title
-	postService.DB.Save(post)
+	// Saving the preloaded author would run its create hooks and replace its UUID
+	postService.DB.Omit("User").Save(post)
 	postService.invalidatePostsCache(post.Domain.String())
 }
 
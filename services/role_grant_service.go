@@ -0,0 +1,148 @@
+package services
+
+import (
+	"goweb/api"
+	"goweb/models"
+	"goweb/requests"
+	"goweb/server"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// RoleGrantSweepInterval is the time between two runs of the sweeper ending expired role grants
+const RoleGrantSweepInterval = time.Minute
+
+// RoleGrantService assigns roles for a limited time and ends the grants once they expire. The enforcer
+// ignores an expired grant right away, the sweeper removes its assignment afterwards.
+type RoleGrantService struct {
+	DB           *gorm.DB
+	server       *server.Server
+	roleService  *RoleService
+	auditService *AuditService
+}
+
+func NewRoleGrantService(server *server.Server) *RoleGrantService {
+	return &RoleGrantService{
+		DB:           server.DB,
+		server:       server,
+		roleService:  NewRoleService(server),
+		auditService: NewAuditService(),
+	}
+}
+
+// Grant assigns the role of the domain to the user from valid_from, or now, until valid_until, with the checks
+// of RoleService.AssignToUser
+func (service *RoleGrantService) Grant(subject string, user *models.User, request *requests.RoleAssignmentRequest, domain *models.Domain) (*models.RoleGrant, error) {
+	if err := service.roleService.checkAssignable(subject, request.Role, domain); err != nil {
+		return nil, err
+	}
+	assigned, err := service.server.Casbin.HasGroupingPolicy(user.UUID.String(), request.Role, domain.UUID.String())
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to assign role")
+	}
+	if assigned {
+		return nil, api.RESOURCE_EXISTS("Role already assigned")
+	}
+
+	grant := &models.RoleGrant{
+		Subject:    user.UUID.String(),
+		Role:       request.Role,
+		Domain:     domain.UUID.String(),
+		ValidFrom:  time.Now(),
+		ValidUntil: *request.ValidUntil,
+		GrantedBy:  subject,
+	}
+	if request.ValidFrom != nil {
+		grant.ValidFrom = *request.ValidFrom
+	}
+	if err := service.DB.Create(grant).Error; err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to assign role")
+	}
+
+	// The window has to be known before the assignment, otherwise the role would be in effect unbounded
+	if err := service.server.RoleGrants.Reload(); err != nil {
+		service.DB.Unscoped().Delete(grant)
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to assign role")
+	}
+	added, err := service.server.Casbin.AddRoleForUserInDomain(grant.Subject, grant.Role, grant.Domain)
+	if err != nil || !added {
+		service.DB.Unscoped().Delete(grant)
+		service.server.RoleGrants.Reload()
+		if err != nil {
+			return nil, api.INTERNAL_SERVICE_ERROR("Failed to assign role")
+		}
+		return nil, api.RESOURCE_EXISTS("Role already assigned")
+	}
+	return grant, nil
+}
+
+// List returns the role grants of the user in the domain, newest first, including the ended ones
+func (service *RoleGrantService) List(user *models.User, domain *models.Domain) ([]models.RoleGrant, error) {
+	grants := []models.RoleGrant{}
+	if err := service.DB.Where("subject = ? AND domain = ?", user.UUID.String(), domain.UUID.String()).
+		Order("created_at DESC").Find(&grants).Error; err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to fetch role grants")
+	}
+	return grants, nil
+}
+
+// Sweep ends the grants expired at the time and removes their assignments, it returns the number of grants ended
+func (service *RoleGrantService) Sweep(at time.Time) int {
+	var expired []models.RoleGrant
+	if err := service.DB.Where("ended_at IS NULL AND valid_until <= ?", at).Find(&expired).Error; err != nil {
+		log.Error().Str("event", "role_grants_sweep_failed").Err(err).Msg("Failed to fetch expired role grants")
+		return 0
+	}
+
+	swept := 0
+	for _, grant := range expired {
+		// The assignment goes first, a grant marked ended would no longer restrict it
+		if _, err := service.server.Casbin.DeleteRoleForUserInDomain(grant.Subject, grant.Role, grant.Domain); err != nil {
+			log.Error().Str("event", "role_grants_sweep_failed").Err(err).Str("grant", grant.UUID.String()).
+				Msg("Failed to remove expired role assignment")
+			continue
+		}
+		ended := service.DB.Model(&models.RoleGrant{}).Where("id = ? AND ended_at IS NULL", grant.ID).
+			Updates(map[string]interface{}{"ended_at": at, "end_reason": models.GrantExpired})
+		if ended.Error != nil || ended.RowsAffected == 0 {
+			continue
+		}
+		swept++
+
+		var user models.User
+		service.DB.Select("id").Where("uuid = ?", grant.Subject).First(&user)
+		service.auditService.Record(AuditEvent{
+			Event:  "role_grant_expired",
+			UserID: user.ID,
+			Details: map[string]interface{}{
+				"domain":      grant.Domain,
+				"role":        grant.Role,
+				"valid_from":  grant.ValidFrom,
+				"valid_until": grant.ValidUntil,
+				"granted_by":  grant.GrantedBy,
+			},
+		})
+	}
+
+	if swept > 0 {
+		if err := service.server.RoleGrants.Reload(); err != nil {
+			log.Error().Str("event", "role_grants_reload_failed").Err(err).Msg("Failed to reload role grants")
+		}
+		log.Info().Str("event", "role_grants_swept").Int("count", swept).Msg("Expired role grants ended")
+	}
+	return swept
+}
+
+// StartRoleGrantSweeper ends expired role grants every RoleGrantSweepInterval. Every instance sweeps, ending a
+// grant is guarded so that only one of them records it.
+func StartRoleGrantSweeper(server *server.Server) {
+	service := NewRoleGrantService(server)
+	go func() {
+		service.Sweep(time.Now())
+		for at := range time.Tick(RoleGrantSweepInterval) {
+			service.Sweep(at)
+		}
+	}()
+}
@@ -5,48 +5,86 @@ import (
 	"goweb/api"
 	"goweb/models"
 	"goweb/requests"
-	"net/http"
+	"goweb/server"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
+// CasbinTable is the table the gorm adapter stores the Casbin rules in, see server.NewServer
+const CasbinTable = "casbin"
+
+// policyTypes are the Casbin policy types granting permissions to roles
+var policyTypes = []string{"p", server.ConditionalPolicy}
+
 type RoleService struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	server *server.Server
 }
 
-func NewRoleService(db *gorm.DB) *RoleService {
-	return &RoleService{DB: db}
+func NewRoleService(server *server.Server) *RoleService {
+	return &RoleService{DB: server.DB, server: server}
 }
 
+// GetRolesInDomain returns the roles of the domain and the roles of the System domain shared by all domains
 func (service *RoleService) GetRolesInDomain(roles *[]*models.Role, domain *models.Domain) error {
-	// TODO: Change the static value 1 for System domain & fetch it dynamically
 	return service.DB.
-		Where("domain_id in (1, ?)", domain.ID).
+		Where("domain_id in (?, ?)", service.systemDomain().ID, domain.ID).
 		Find(roles).Error
 }
 
-func (service *RoleService) Create(e echo.Context, request *requests.RoleRequest, domain *models.Domain) error {
+// systemDomain loads the System domain, an empty domain if it does not exist
+func (service *RoleService) systemDomain() *models.Domain {
+	system := &models.Domain{}
+	NewDomainService(service.DB).GetSystemDomain(system)
+	return system
+}
+
+// Create creates a role in the domain, inheriting the permissions of the parent role if the request names one
+func (service *RoleService) Create(subject string, request *requests.RoleRequest, domain *models.Domain) (*models.Role, error) {
 	var role models.Role
 
 	service.DB.
 		Where("name = ? AND domain_id = ?", request.Name, domain.ID).
-		First(role)
+		First(&role)
 
 	if role.ID != 0 {
-		return api.WebResponse(e, http.StatusBadRequest, api.RESOURCE_EXISTS("Role already exists"))
+		return nil, api.RESOURCE_EXISTS("Role already exists")
 	}
 
 	role.Name = request.Name
 	role.DomainID = domain.ID
+	if err := service.checkParent(subject, &role, request.Parent, domain); err != nil {
+		return nil, err
+	}
 
-	ok := service.DB.Create(&role).Error
-
-	if ok != nil {
-		return api.WebResponse(e, http.StatusInternalServerError, api.RESOURCE_CREATION_FAILED("Error creating role"))
+	if err := service.DB.Create(&role).Error; err != nil {
+		return nil, api.RESOURCE_CREATION_FAILED("Error creating role")
+	}
+	if err := service.setParent(&role, request.Parent, domain); err != nil {
+		return nil, err
 	}
+	return &role, nil
+}
 
-	return api.WebResponse(e, http.StatusCreated, api.RESOURCE_CREATED("Role created"))
+// Update renames the role and changes the role it inherits from, the parent is checked before anything is changed
+func (service *RoleService) Update(subject string, role *models.Role, request *requests.RoleRequest, domain *models.Domain) error {
+	parentChanged := request.Parent != service.Parent(role, domain)
+	if parentChanged {
+		if err := service.checkParent(subject, role, request.Parent, domain); err != nil {
+			return err
+		}
+	}
+	if err := service.RenameRole(role, request.Name); err != nil {
+		return err
+	}
+	if !parentChanged {
+		return nil
+	}
+	return service.setParent(role, request.Parent, domain)
 }
 
 func (service *RoleService) GetRoleByUuidInDomain(role *models.Role, uuid string, domain *models.Domain) error {
@@ -55,19 +93,527 @@ func (service *RoleService) GetRoleByUuidInDomain(role *models.Role, uuid string
 		First(role).Error
 }
 
-func (service *RoleService) UpdateRole(role *models.Role) error {
-	return service.DB.
-		Save(role).Error
+// RenameRole renames the role with its policies, links to parent and child roles, user assignments, grants and API keys.
+// The rules are rewritten in the database transaction renaming the role, the enforcer reloads them once it committed.
+func (service *RoleService) RenameRole(role *models.Role, name string) error {
+	if name == role.Name {
+		return nil
+	}
+	domains := service.roleDomains(role)
+	if service.nameTaken(name, role, domains) {
+		return api.RESOURCE_EXISTS("Role already exists")
+	}
+
+	oldName := role.Name
+	err := service.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(role).Update("name", name).Error; err != nil {
+			return err
+		}
+		if len(domains) == 0 {
+			return nil
+		}
+		if err := tx.Table(CasbinTable).Where("ptype IN ? AND v0 = ? AND v1 IN ?", policyTypes, oldName, domains).Update("v0", name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.APIKey{}).Where("role = ? AND domain IN ?", oldName, domains).Update("role", name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RoleGrant{}).Where("role = ? AND domain IN ?", oldName, domains).Update("role", name).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(CasbinTable).Where("ptype = ? AND v0 = ? AND v2 IN ?", "g", oldName, domains).Update("v0", name).Error; err != nil {
+			return err
+		}
+		return tx.Table(CasbinTable).Where("ptype = ? AND v1 = ? AND v2 IN ?", "g", oldName, domains).Update("v1", name).Error
+	})
+	if err != nil {
+		role.Name = oldName
+		return api.INTERNAL_SERVICE_ERROR("Failed to update role")
+	}
+	return service.reloadPolicy()
 }
 
+// DeleteRoleByUuidInDomain deletes the role with its policies, links to parent and child roles and user assignments.
+// Its time-bound grants are ended in the same database transaction, which works like the one of RenameRole.
+// Child roles no longer inherit from it.
 func (service *RoleService) DeleteRoleByUuidInDomain(role *models.Role, uuid string, domain *models.Domain) error {
-	records := service.DB.
-		Where("domain_id = ? AND uuid = ?", domain.ID, uuid).
-		Delete(role)
+	if err := service.GetRoleByUuidInDomain(role, uuid, domain); err != nil {
+		return api.RESOURCE_NOT_FOUND("Role not found")
+	}
+
+	domains := service.roleDomains(role)
+	err := service.DB.Transaction(func(tx *gorm.DB) error {
+		records := tx.Delete(role)
+		if records.Error != nil {
+			return records.Error
+		}
+		if records.RowsAffected == 0 {
+			return errors.New("role already deleted")
+		}
+		if len(domains) == 0 {
+			return nil
+		}
+		if err := tx.Table(CasbinTable).Where("ptype IN ? AND v0 = ? AND v1 IN ?", policyTypes, role.Name, domains).Delete(nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(CasbinTable).Where("ptype = ? AND v0 = ? AND v2 IN ?", "g", role.Name, domains).Delete(nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RoleGrant{}).Where("role = ? AND domain IN ? AND ended_at IS NULL", role.Name, domains).
+			Updates(map[string]interface{}{"ended_at": time.Now(), "end_reason": models.GrantRoleDeleted}).Error; err != nil {
+			return err
+		}
+		return tx.Table(CasbinTable).Where("ptype = ? AND v1 = ? AND v2 IN ?", "g", role.Name, domains).Delete(nil).Error
+	})
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to delete role")
+	}
+	return service.reloadPolicy()
+}
+
+// nameTaken reports whether renaming the role to name clashes with a role or with policies in the domains it applies to
+func (service *RoleService) nameTaken(name string, role *models.Role, domains []string) bool {
+	query := service.DB.Model(&models.Role{}).Where("name = ?", name)
+	if system := service.systemDomain(); role.DomainID != system.ID {
+		query = query.Where("domain_id IN (?, ?)", system.ID, role.DomainID)
+	}
+	var roles int64
+	query.Count(&roles)
+	if roles > 0 {
+		return true
+	}
+
+	for _, domain := range domains {
+		if policies, _ := service.policiesIn(name, domain); len(policies) > 0 {
+			return true
+		}
+		if assignments, _ := service.server.Casbin.GetFilteredGroupingPolicy(1, name, domain); len(assignments) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadPolicy loads the rules and grants written around the enforcer into it and announces them to the other instances
+func (service *RoleService) reloadPolicy() error {
+	if err := service.server.Casbin.LoadPolicy(); err != nil {
+		log.Error().Str("event", "casbin_reload_failed").Err(err).Msg("Failed to reload Casbin policies")
+		return api.INTERNAL_SERVICE_ERROR("Failed to reload permissions")
+	}
+	if err := service.server.RoleGrants.Reload(); err != nil {
+		log.Error().Str("event", "role_grants_reload_failed").Err(err).Msg("Failed to reload role grants")
+		return api.INTERNAL_SERVICE_ERROR("Failed to reload permissions")
+	}
+	// The rules were written around the enforcer, so it did not announce the change itself
+	if service.server.PolicyWatcher != nil {
+		service.server.PolicyWatcher.Update()
+	}
+	return nil
+}
+
+// roleDomains lists the domains the role applies to. Roles of the System domain are listed in every domain,
+// except in domains defining a role of the same name themselves, and in server.RoleTemplateDomain.
+func (service *RoleService) roleDomains(role *models.Role) []string {
+	var domains []models.Domain
+	uuids := []string{}
+	if system := service.systemDomain(); role.DomainID != system.ID {
+		service.DB.Where("id = ?", role.DomainID).Find(&domains)
+	} else {
+		service.DB.Where("id NOT IN (?)", service.DB.Model(&models.Role{}).Select("domain_id").Where("name = ? AND domain_id <> ?", role.Name, system.ID)).Find(&domains)
+		uuids = append(uuids, server.RoleTemplateDomain)
+	}
+
+	for _, domain := range domains {
+		uuids = append(uuids, domain.UUID.String())
+	}
+	return uuids
+}
+
+// linkDomain is the domain of the links from the domain's roles to their parents, the links of the System
+// domain's roles are templates for every domain
+func (service *RoleService) linkDomain(domain *models.Domain) string {
+	if system := service.systemDomain(); system.ID != 0 && system.ID == domain.ID {
+		return server.RoleTemplateDomain
+	}
+	return domain.UUID.String()
+}
+
+// Parent returns the role the role of the domain inherits from, empty if it inherits from none
+func (service *RoleService) Parent(role *models.Role, domain *models.Domain) string {
+	links, err := service.server.Casbin.GetFilteredGroupingPolicy(0, role.Name, "", service.linkDomain(domain))
+	if err != nil || len(links) == 0 {
+		return ""
+	}
+	return links[0][1]
+}
+
+// checkParent validates the parent of the role of the domain: it must be a role of the domain that does not
+// inherit from the role, and the subject must hold its permissions, as inheriting grants them to the role
+func (service *RoleService) checkParent(subject string, role *models.Role, parent string, domain *models.Domain) error {
+	if parent == "" {
+		return nil
+	}
+	if parent == role.Name {
+		return api.ROLE_CYCLE("A role cannot inherit from itself")
+	}
+	if !service.RoleExistsInDomain(parent, domain) {
+		return api.RESOURCE_NOT_FOUND("Parent role not found")
+	}
+
+	// Links of the System domain's roles apply in every domain, together with the links each domain adds
+	domains := []string{domain.UUID.String()}
+	if service.linkDomain(domain) == server.RoleTemplateDomain {
+		domains = nil
+		service.DB.Model(&models.Domain{}).Pluck("uuid", &domains)
+	}
+	for _, dom := range domains {
+		ancestors, err := service.server.Casbin.GetImplicitRolesForUser(parent, dom)
+		if err != nil {
+			return api.INTERNAL_SERVICE_ERROR("Failed to resolve roles")
+		}
+		for _, ancestor := range ancestors {
+			if ancestor == role.Name {
+				return api.ROLE_CYCLE(parent + " inherits from " + role.Name + " already")
+			}
+		}
+	}
+
+	policies, err := service.EffectivePolicies(parent, domain)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	return service.holds(subject, policies, domain, "You can only inherit roles whose permissions you hold")
+}
+
+// setParent replaces the link of the role of the domain to its parent, an empty parent removes it
+func (service *RoleService) setParent(role *models.Role, parent string, domain *models.Domain) error {
+	linkDomain := service.linkDomain(domain)
+	if _, err := service.server.Casbin.RemoveFilteredGroupingPolicy(0, role.Name, "", linkDomain); err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to update parent role")
+	}
+	if parent == "" {
+		return nil
+	}
+	if _, err := service.server.Casbin.AddGroupingPolicy(role.Name, parent, linkDomain); err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to update parent role")
+	}
+	return nil
+}
+
+// RoleExistsInDomain reports whether the role is one of the domain's roles, as listed by /api/role
+func (service *RoleService) RoleExistsInDomain(name string, domain *models.Domain) bool {
+	var roles []*models.Role
+	if err := service.GetRolesInDomain(&roles, domain); err != nil {
+		return false
+	}
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePolicies returns the policies and conditional policies the role has in the domain: its own policies
+// in the domain or, if the domain defines none, the role's template policies in the System domain
+func (service *RoleService) RolePolicies(name string, domain *models.Domain) ([][]string, error) {
+	policies, err := service.policiesIn(name, domain.UUID.String())
+	if err != nil || len(policies) > 0 {
+		return policies, err
+	}
+	system := service.systemDomain()
+	if system.ID == 0 || system.ID == domain.ID {
+		return policies, nil
+	}
+	return service.policiesIn(name, system.UUID.String())
+}
+
+// policiesIn returns the policies of the role in the domain followed by its conditional policies there
+func (service *RoleService) policiesIn(name, domain string) ([][]string, error) {
+	policies, err := service.server.Casbin.GetFilteredPolicy(0, name, domain)
+	if err != nil {
+		return nil, err
+	}
+	conditional, err := service.server.Casbin.GetFilteredNamedPolicy(server.ConditionalPolicy, 0, name, domain)
+	if err != nil {
+		return nil, err
+	}
+	return append(policies, conditional...), nil
+}
+
+// EffectivePolicies returns the policies of the role in the domain together with those of the roles it
+// inherits from, directly or through other roles
+func (service *RoleService) EffectivePolicies(name string, domain *models.Domain) ([][]string, error) {
+	ancestors, err := service.server.Casbin.GetImplicitRolesForUser(name, domain.UUID.String())
+	if err != nil {
+		return nil, err
+	}
+	var policies [][]string
+	for _, role := range append([]string{name}, ancestors...) {
+		rolePolicies, err := service.RolePolicies(role, domain)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, rolePolicies...)
+	}
+	return policies, nil
+}
+
+// GetRolesOfUser returns the roles the user is assigned in the domain, sorted by name. Time-bound grants are
+// left out outside their validity.
+func (service *RoleService) GetRolesOfUser(user *models.User, domain *models.Domain) ([]string, error) {
+	assigned, err := service.server.Casbin.GetRolesForUser(user.UUID.String(), domain.UUID.String())
+	if err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to fetch roles")
+	}
+	now := time.Now()
+	roles := make([]string, 0, len(assigned))
+	for _, role := range assigned {
+		if service.server.RoleGrants.Active(user.UUID.String(), role, domain.UUID.String(), now) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+// AssignToUser assigns a role of the domain to the user. The subject can only assign roles whose permissions,
+// including the inherited ones, it holds itself, so the endpoint cannot be used to escalate its own rights.
+func (service *RoleService) AssignToUser(subject string, user *models.User, name string, domain *models.Domain) error {
+	if err := service.checkAssignable(subject, name, domain); err != nil {
+		return err
+	}
+
+	added, err := service.server.Casbin.AddRoleForUserInDomain(user.UUID.String(), name, domain.UUID.String())
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to assign role")
+	}
+	if !added {
+		return api.RESOURCE_EXISTS("Role already assigned")
+	}
+	return nil
+}
+
+// checkAssignable checks that the role is one of the domain's roles and that the subject holds its permissions
+func (service *RoleService) checkAssignable(subject string, name string, domain *models.Domain) error {
+	if !service.RoleExistsInDomain(name, domain) {
+		return api.RESOURCE_NOT_FOUND("Role not found")
+	}
+
+	policies, err := service.EffectivePolicies(name, domain)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	return service.holds(subject, policies, domain, "You can only assign roles whose permissions you hold")
+}
+
+// holds returns CASBIN_UNAUTHORIZED with the message unless the subject holds every permission of the policies
+// in the domain. A conditional permission is held by holding it on the subject's own resources.
+func (service *RoleService) holds(subject string, policies [][]string, domain *models.Domain, message string) error {
+	for _, policy := range policies {
+		owner := ""
+		if len(policy) > 4 {
+			owner = subject
+		}
+		held, err := service.server.Authorize(subject, domain.UUID.String(), policy[2], policy[3], owner)
+		if err != nil {
+			return api.INTERNAL_SERVICE_ERROR("Casbin enforcement error")
+		}
+		if !held {
+			return api.CASBIN_UNAUTHORIZED(message)
+		}
+	}
+	return nil
+}
+
+// UnassignFromUser removes a role of the domain from the user, revoking a time-bound grant of it early.
+// Like assignments, only roles whose permissions the subject holds can be removed.
+func (service *RoleService) UnassignFromUser(subject string, user *models.User, name string, domain *models.Domain) error {
+	assigned, err := service.server.Casbin.HasGroupingPolicy(user.UUID.String(), name, domain.UUID.String())
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to unassign role")
+	}
+	if !assigned {
+		return api.RESOURCE_NOT_FOUND("Role not assigned")
+	}
+	policies, err := service.EffectivePolicies(name, domain)
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to fetch permissions")
+	}
+	if err := service.holds(subject, policies, domain, "You can only unassign roles whose permissions you hold"); err != nil {
+		return err
+	}
+
+	removed, err := service.server.Casbin.DeleteRoleForUserInDomain(user.UUID.String(), name, domain.UUID.String())
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to unassign role")
+	}
+	if !removed {
+		return api.RESOURCE_NOT_FOUND("Role not assigned")
+	}
 
-	if records.RowsAffected > 0 {
+	ended := service.DB.Model(&models.RoleGrant{}).
+		Where("subject = ? AND role = ? AND domain = ? AND ended_at IS NULL", user.UUID.String(), name, domain.UUID.String()).
+		Updates(map[string]interface{}{"ended_at": time.Now(), "end_reason": models.GrantRevoked})
+	if ended.Error != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to revoke role grant")
+	}
+	if ended.RowsAffected == 0 {
 		return nil
 	}
+	if err := service.server.RoleGrants.Reload(); err != nil {
+		log.Error().Str("event", "role_grants_reload_failed").Err(err).Msg("Failed to reload role grants")
+		return api.INTERNAL_SERVICE_ERROR("Failed to reload permissions")
+	}
+	// The grant was ended around the enforcer, the other instances only learn of it through the announcement
+	if service.server.PolicyWatcher != nil {
+		service.server.PolicyWatcher.Update()
+	}
+	return nil
+}
+
+// Kinds of drift reported by CheckConsistency
+const (
+	DriftUnknownRole    = "unknown_role"    // policy or assignment of a role without role record in the domain or System
+	DriftUnknownDomain  = "unknown_domain"  // rule for a domain UUID that does not exist
+	DriftUnknownSubject = "unknown_subject" // assignment to neither a user, an API key nor a role
+	DriftUnusedRole     = "unused_role"     // role record without policies or parent, informational
+)
+
+// RoleDrift is a mismatch between the roles table and the Casbin rules
+type RoleDrift struct {
+	Kind   string
+	Domain string
+	Role   string
+	Rule   string
+}
+
+// CheckConsistency compares the roles table with the rules loaded into the enforcer
+func (service *RoleService) CheckConsistency() ([]RoleDrift, error) {
+	var domains []models.Domain
+	if err := service.DB.Find(&domains).Error; err != nil {
+		return nil, err
+	}
+	domainUUIDs := make(map[uint64]string, len(domains))
+	for _, domain := range domains {
+		domainUUIDs[domain.ID] = domain.UUID.String()
+	}
+
+	var roles []models.Role
+	if err := service.DB.Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	system := service.systemDomain()
+	systemRoles := map[string]bool{}
+	domainRoles := map[string]map[string]bool{}
+	for _, role := range roles {
+		if role.DomainID == system.ID {
+			systemRoles[role.Name] = true
+			continue
+		}
+		domain := domainUUIDs[role.DomainID]
+		if domainRoles[domain] == nil {
+			domainRoles[domain] = map[string]bool{}
+		}
+		domainRoles[domain][role.Name] = true
+	}
+
+	subjects := map[string]bool{}
+	var uuids []string
+	if err := service.DB.Model(&models.User{}).Pluck("uuid", &uuids).Error; err != nil {
+		return nil, err
+	}
+	for _, uuid := range uuids {
+		subjects[uuid] = true
+	}
+	uuids = nil
+	if err := service.DB.Model(&models.APIKey{}).Pluck("uuid", &uuids).Error; err != nil {
+		return nil, err
+	}
+	for _, uuid := range uuids {
+		subjects[uuid] = true
+	}
+
+	knownDomains := map[string]bool{server.RoleTemplateDomain: true}
+	for _, domain := range domainUUIDs {
+		knownDomains[domain] = true
+	}
+
+	var drifts []RoleDrift
+	usedRoles := map[string]bool{}
+	check := func(rule []string, role, domain string) {
+		text := strings.Join(rule, ", ")
+		if !knownDomains[domain] {
+			drifts = append(drifts, RoleDrift{Kind: DriftUnknownDomain, Domain: domain, Role: role, Rule: text})
+			return
+		}
+		if !systemRoles[role] && !domainRoles[domain][role] {
+			drifts = append(drifts, RoleDrift{Kind: DriftUnknownRole, Domain: domain, Role: role, Rule: text})
+		}
+	}
+
+	policies, err := service.server.Casbin.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	conditional, err := service.server.Casbin.GetNamedPolicy(server.ConditionalPolicy)
+	if err != nil {
+		return nil, err
+	}
+	for i, policy := range append(policies, conditional...) {
+		if len(policy) < 2 {
+			continue
+		}
+		ptype := "p"
+		if i >= len(policies) {
+			ptype = server.ConditionalPolicy
+		}
+		usedRoles[policy[1]+"/"+policy[0]] = true
+		check(append([]string{ptype}, policy...), policy[0], policy[1])
+	}
+
+	assignments, err := service.server.Casbin.GetGroupingPolicy()
+	if err != nil {
+		return nil, err
+	}
+	for _, assignment := range assignments {
+		if len(assignment) < 3 {
+			continue
+		}
+		rule := append([]string{"g"}, assignment...)
+		check(rule, assignment[1], assignment[2])
+		// Links between roles have the inheriting role as subject
+		if systemRoles[assignment[0]] || domainRoles[assignment[2]][assignment[0]] {
+			usedRoles[assignment[2]+"/"+assignment[0]] = true
+			continue
+		}
+		if !subjects[assignment[0]] {
+			drifts = append(drifts, RoleDrift{Kind: DriftUnknownSubject, Domain: assignment[2], Role: assignment[1], Rule: strings.Join(rule, ", ")})
+		}
+	}
+
+	for _, role := range roles {
+		used := false
+		if role.DomainID == system.ID {
+			for domain := range knownDomains {
+				used = used || usedRoles[domain+"/"+role.Name]
+			}
+		} else {
+			used = usedRoles[domainUUIDs[role.DomainID]+"/"+role.Name]
+		}
+		if !used {
+			drifts = append(drifts, RoleDrift{Kind: DriftUnusedRole, Domain: domainUUIDs[role.DomainID], Role: role.Name})
+		}
+	}
 
-	return errors.New("Failed")
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Kind != drifts[j].Kind {
+			return drifts[i].Kind < drifts[j].Kind
+		}
+		if drifts[i].Domain != drifts[j].Domain {
+			return drifts[i].Domain < drifts[j].Domain
+		}
+		return drifts[i].Rule < drifts[j].Rule
+	})
+	return drifts, nil
 }
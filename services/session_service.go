@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"goweb/server"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+const SessionCacheKey = "tokens:session:%s"
+const UserSessionsCacheKey = "tokens:user:%d:sessions"
+
+// DeviceHeader lets clients name the device a session is created from
+const DeviceHeader = "X-Device-Name"
+
+// updateSessionScript updates session fields only while the session still exists,
+// so a concurrent revoke is never undone by a late update.
+// The user's session index is prolonged with it, it has to outlive every session it lists.
+var updateSessionScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], unpack(ARGV, 3))
+redis.call('EXPIRE', KEYS[1], ARGV[1])
+redis.call('EXPIRE', KEYS[2], ARGV[2])
+return 1
+`)
+
+// Session represents a single login of a user on one device.
+// The session ID is shared by all tokens rotated from that login (the token family).
+type Session struct {
+	ID         string `json:"id" redis:"id"`
+	UserID     uint64 `json:"-" redis:"user_id"`
+	AccessUID  string `json:"-" redis:"access"`
+	RefreshUID string `json:"-" redis:"refresh"`
+	Device     string `json:"device" redis:"device"`
+	UserAgent  string `json:"user_agent" redis:"user_agent"`
+	IP         string `json:"ip" redis:"ip"`
+	CreatedAt  int64  `json:"created_at" redis:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at" redis:"last_seen_at"`
+}
+
+// ClientInfo describes the client a session is created for
+type ClientInfo struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
+
+// NewClientInfo extracts the client information from the request
+func NewClientInfo(c echo.Context) ClientInfo {
+	userAgent := c.Request().UserAgent()
+	device := c.Request().Header.Get(DeviceHeader)
+	if device == "" {
+		device = describeUserAgent(userAgent)
+	}
+	return ClientInfo{
+		Device:    device,
+		UserAgent: userAgent,
+		IP:        c.RealIP(),
+	}
+}
+
+type SessionService struct {
+	server *server.Server
+}
+
+func NewSessionService(server *server.Server) *SessionService {
+	return &SessionService{server: server}
+}
+
+// Create stores a new session and registers it with the user's session index
+func (service *SessionService) Create(session *Session) error {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+
+	key := fmt.Sprintf(SessionCacheKey, session.ID)
+	userKey := fmt.Sprintf(UserSessionsCacheKey, session.UserID)
+
+	_, err := service.server.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, session)
+		pipe.Expire(ctx, key, time.Minute*AutoLogoffMinutes)
+		pipe.SAdd(ctx, userKey, session.ID)
+		pipe.Expire(ctx, userKey, time.Minute*ExpireRefreshMinutes)
+		return nil
+	})
+	return err
+}
+
+// Get loads a session by its ID
+func (service *SessionService) Get(session *Session, sessionID string) error {
+	err := service.server.Redis.HGetAll(context.Background(), fmt.Sprintf(SessionCacheKey, sessionID)).Scan(session)
+	if err != nil {
+		return err
+	}
+	if session.ID == "" {
+		return redis.Nil
+	}
+	return nil
+}
+
+// Rotate replaces the token IDs bound to the session
+func (service *SessionService) Rotate(userID uint64, sessionID, accessUID, refreshUID string, client ClientInfo) error {
+	return service.update(userID, sessionID,
+		"access", accessUID,
+		"refresh", refreshUID,
+		"ip", client.IP,
+		"last_seen_at", time.Now().Unix(),
+	)
+}
+
+// Touch records activity on the session and prolongs its lifetime
+func (service *SessionService) Touch(userID uint64, sessionID, ip string) error {
+	return service.update(userID, sessionID,
+		"ip", ip,
+		"last_seen_at", time.Now().Unix(),
+	)
+}
+
+// ListForUser returns the active sessions of a user, most recently used first
+func (service *SessionService) ListForUser(userID uint64) ([]*Session, error) {
+	ctx := context.Background()
+	userKey := fmt.Sprintf(UserSessionsCacheKey, userID)
+
+	ids, err := service.server.Redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = service.server.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, fmt.Sprintf(SessionCacheKey, id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for i, cmd := range cmds {
+		session := new(Session)
+		if err := cmd.Scan(session); err != nil || session.ID == "" {
+			// Session expired, drop it from the index
+			service.server.Redis.SRem(ctx, userKey, ids[i])
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt > sessions[j].LastSeenAt
+	})
+	return sessions, nil
+}
+
+// Revoke deletes a single session of the user
+func (service *SessionService) Revoke(userID uint64, sessionID string) error {
+	ctx := context.Background()
+	_, err := service.server.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, fmt.Sprintf(SessionCacheKey, sessionID))
+		pipe.SRem(ctx, fmt.Sprintf(UserSessionsCacheKey, userID), sessionID)
+		return nil
+	})
+	return err
+}
+
+// RevokeAll deletes every session of the user
+func (service *SessionService) RevokeAll(userID uint64) error {
+	ctx := context.Background()
+	userKey := fmt.Sprintf(UserSessionsCacheKey, userID)
+
+	ids, err := service.server.Redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userKey}
+	for _, id := range ids {
+		keys = append(keys, fmt.Sprintf(SessionCacheKey, id))
+	}
+	return service.server.Redis.Del(ctx, keys...).Err()
+}
+
+// update sets the session's fields and prolongs the session and the user's session index. Every update
+// follows a token issued with the refresh token lifetime at most, so the index outlives every session.
+func (service *SessionService) update(userID uint64, sessionID string, fields ...interface{}) error {
+	ttl := int((time.Minute * AutoLogoffMinutes).Seconds())
+	indexTTL := int((time.Minute * ExpireRefreshMinutes).Seconds())
+	args := append([]interface{}{ttl, indexTTL}, fields...)
+	return updateSessionScript.Run(context.Background(), service.server.Redis,
+		[]string{fmt.Sprintf(SessionCacheKey, sessionID), fmt.Sprintf(UserSessionsCacheKey, userID)}, args...).Err()
+}
+
+// describeUserAgent derives a readable device name from the user agent
+func describeUserAgent(userAgent string) string {
+	devices := []struct{ marker, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "Mac"},
+		{"Linux", "Linux"},
+	}
+	for _, d := range devices {
+		if strings.Contains(userAgent, d.marker) {
+			return d.name
+		}
+	}
+	return "Unknown device"
+}
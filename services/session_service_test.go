@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"goweb/server"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newSessionTestService(t *testing.T) (*SessionService, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return NewSessionService(&server.Server{Redis: redis.NewClient(&redis.Options{Addr: mr.Addr()})}), mr
+}
+
+// Sessions kept alive by refresh rotation beyond the index's initial lifetime must still be listed and revoked
+func TestRevokeAllAfterRotatingPastIndexTTL(t *testing.T) {
+	service, mr := newSessionTestService(t)
+	const userID = 7
+	if err := service.Create(&Session{ID: "family", UserID: userID}); err != nil {
+		t.Fatal(err)
+	}
+
+	step := time.Minute * (AutoLogoffMinutes - 1)
+	for elapsed := time.Duration(0); elapsed <= time.Minute*ExpireRefreshMinutes; elapsed += step {
+		mr.FastForward(step)
+		if err := service.Rotate(userID, "family", "access", "refresh", ClientInfo{IP: "192.0.2.1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions, err := service.ListForUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != "family" {
+		t.Fatalf("expected the rotated session to be listed, got %v", sessions)
+	}
+
+	if err := service.RevokeAll(userID); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(fmt.Sprintf(SessionCacheKey, "family")) {
+		t.Fatal("session survived RevokeAll")
+	}
+	if n, _ := service.server.Redis.Exists(context.Background(), fmt.Sprintf(UserSessionsCacheKey, userID)).Result(); n != 0 {
+		t.Fatal("session index survived RevokeAll")
+	}
+}
+
+// Touching a session prolongs the index as well
+func TestTouchProlongsIndex(t *testing.T) {
+	service, mr := newSessionTestService(t)
+	if err := service.Create(&Session{ID: "family", UserID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(time.Minute * (AutoLogoffMinutes - 1))
+	if err := service.Touch(7, "family", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL(fmt.Sprintf(UserSessionsCacheKey, 7)); ttl != time.Minute*ExpireRefreshMinutes {
+		t.Fatalf("index not prolonged, ttl %v", ttl)
+	}
+}
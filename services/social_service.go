@@ -2,12 +2,21 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"goweb/api"
 	"goweb/config"
 	"goweb/models"
 	"goweb/server"
 	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"golang.org/x/oauth2"
@@ -16,10 +25,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// SocialStateCacheKey holds the pending OAuth login of a state parameter
+const SocialStateCacheKey = "oauth:state:%s"
+
+const SocialStateMinutes = 10
+
+// OAuthState is a pending social login, from the redirect to the provider until its callback.
+// Binding is the value of the cookie set with the redirect, so only the browser that started the login can complete it.
+type OAuthState struct {
+	Provider   string `json:"provider"`
+	Verifier   string `json:"verifier"`
+	Binding    string `json:"binding"`
+	Nonce      string `json:"nonce"`
+	RedirectTo string `json:"redirect_to,omitempty"`
+	LinkUserID uint64 `json:"link_user_id,omitempty"` // Set when a signed in user links the account instead of logging in
+}
+
 type SocialService struct {
-	server *server.Server
-	db     *gorm.DB
-	config *config.Config
+	server        *server.Server
+	db            *gorm.DB
+	config        *config.Config
+	oidcProviders map[string]*OIDCProvider
 }
 
 type GoogleUserInfo struct {
@@ -31,21 +57,46 @@ type GoogleUserInfo struct {
 }
 
 type GitHubUserInfo struct {
-	ID        int    `json:"id"`
-	Login     string `json:"login"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	AvatarURL string `json:"avatar_url"`
+	ID            int    `json:"id"`
+	Login         string `json:"login"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	AvatarURL     string `json:"avatar_url"`
+	EmailVerified bool   `json:"-"` // from /user/emails, the profile does not tell
 }
 
+// oidcProviderName keeps provider names usable as path segment
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9-]+$`)
+
 func NewSocialService(server *server.Server) *SocialService {
+	providers := make(map[string]*OIDCProvider)
+	for _, provider := range server.Config.Auth.Social.OIDC {
+		// google and github keep their dedicated routes
+		if provider.Name == "google" || provider.Name == "github" || !oidcProviderName.MatchString(provider.Name) {
+			log.Warn().Str("event", "oidc_provider_skipped").Str("provider", provider.Name).Msg("OIDC provider name is reserved or not a valid path segment")
+			continue
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Warn().Str("event", "oidc_provider_skipped").Str("provider", provider.Name).Msg("OIDC provider misses issuer or client ID")
+			continue
+		}
+		providers[provider.Name] = NewOIDCProvider(provider)
+	}
+
 	return &SocialService{
-		server: server,
-		db:     server.DB,
-		config: server.Config,
+		server:        server,
+		db:            server.DB,
+		config:        server.Config,
+		oidcProviders: providers,
 	}
 }
 
+// GetOIDCProvider returns the configured OpenID Connect provider of the name
+func (s *SocialService) GetOIDCProvider(name string) (*OIDCProvider, bool) {
+	provider, ok := s.oidcProviders[name]
+	return provider, ok
+}
+
 // GetGoogleOAuthConfig returns the OAuth2 configuration for Google
 func (s *SocialService) GetGoogleOAuthConfig() *oauth2.Config {
 	return &oauth2.Config{
@@ -74,6 +125,81 @@ func (s *SocialService) GetGitHubOAuthConfig() *oauth2.Config {
 	}
 }
 
+// CreateOAuthState starts a social login, returning the state parameter and the pending login with its PKCE verifier and cookie binding.
+// redirectTo must be a path on the frontend, absolute URLs would turn the login into an open redirect.
+// A linkUserID links the provider account to that user instead of logging in.
+func (s *SocialService) CreateOAuthState(provider, redirectTo string, linkUserID uint64) (string, *OAuthState, error) {
+	if redirectTo != "" && !IsRelativeRedirect(redirectTo) {
+		return "", nil, api.FIELD_VALIDATION_ERROR("redirect_to must be a relative path")
+	}
+
+	state, err := randomURLToken()
+	if err != nil {
+		return "", nil, api.INTERNAL_SERVICE_ERROR("Failed to generate login state")
+	}
+	binding, err := randomURLToken()
+	if err != nil {
+		return "", nil, api.INTERNAL_SERVICE_ERROR("Failed to generate login state")
+	}
+	nonce, err := randomURLToken()
+	if err != nil {
+		return "", nil, api.INTERNAL_SERVICE_ERROR("Failed to generate login state")
+	}
+	pending := &OAuthState{
+		Provider:   provider,
+		Verifier:   oauth2.GenerateVerifier(),
+		Binding:    binding,
+		Nonce:      nonce,
+		RedirectTo: redirectTo,
+		LinkUserID: linkUserID,
+	}
+
+	data, _ := json.Marshal(pending)
+	err = s.server.Redis.Set(context.Background(), fmt.Sprintf(SocialStateCacheKey, state), data, time.Minute*SocialStateMinutes).Err()
+	if err != nil {
+		return "", nil, api.INTERNAL_SERVICE_ERROR("Failed to store login state")
+	}
+	return state, pending, nil
+}
+
+// ConsumeOAuthState returns the pending login of a callback and deletes it, so a state is accepted once.
+// The login must have been started for the same provider and in the browser presenting the binding cookie.
+func (s *SocialService) ConsumeOAuthState(provider, state, binding string) (*OAuthState, error) {
+	if state == "" {
+		return nil, api.INVALID_TOKEN("Missing login state")
+	}
+	data, err := s.server.Redis.GetDel(context.Background(), fmt.Sprintf(SocialStateCacheKey, state)).Bytes()
+	if err != nil {
+		return nil, api.INVALID_TOKEN("Invalid or expired login state")
+	}
+
+	pending := new(OAuthState)
+	if err := json.Unmarshal(data, pending); err != nil {
+		return nil, api.INVALID_TOKEN("Invalid or expired login state")
+	}
+	if pending.Provider != provider || subtle.ConstantTimeCompare([]byte(pending.Binding), []byte(binding)) != 1 {
+		return nil, api.INVALID_TOKEN("Login state does not belong to this browser")
+	}
+	return pending, nil
+}
+
+// IsRelativeRedirect reports whether target is a path on the same origin, without scheme or host
+func IsRelativeRedirect(target string) bool {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.ContainsAny(target, "\\\r\n") {
+		return false
+	}
+	parsed, err := url.Parse(target)
+	return err == nil && parsed.Scheme == "" && parsed.Host == ""
+}
+
+func randomURLToken() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
 // GetGoogleUserInfo fetches user information from Google OAuth
 func (s *SocialService) GetGoogleUserInfo(token *oauth2.Token) (*GoogleUserInfo, error) {
 	client := s.GetGoogleOAuthConfig().Client(context.Background(), token)
@@ -115,22 +241,26 @@ func (s *SocialService) GetGitHubUserInfo(token *oauth2.Token) (*GitHubUserInfo,
 		return nil, fmt.Errorf("failed to unmarshal user info: %w", err)
 	}
 
-	// Get email if not provided in user info
-	if userInfo.Email == "" {
-		emailResp, err := client.Get("https://api.github.com/user/emails")
-		if err == nil {
-			defer emailResp.Body.Close()
-			var emails []struct {
-				Email   string `json:"email"`
-				Primary bool   `json:"primary"`
-			}
-			if emailBody, err := io.ReadAll(emailResp.Body); err == nil {
-				if json.Unmarshal(emailBody, &emails) == nil {
-					for _, email := range emails {
-						if email.Primary {
-							userInfo.Email = email.Email
-							break
-						}
+	// The profile's email may be unconfirmed and is missing unless public, /user/emails tells which addresses
+	// GitHub verified. Without a profile email the primary address is taken, but only once verified.
+	emailResp, err := client.Get("https://api.github.com/user/emails")
+	if err == nil {
+		defer emailResp.Body.Close()
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if emailBody, err := io.ReadAll(emailResp.Body); err == nil {
+			if json.Unmarshal(emailBody, &emails) == nil {
+				for _, email := range emails {
+					if !email.Verified {
+						continue
+					}
+					if strings.EqualFold(email.Email, userInfo.Email) || userInfo.Email == "" && email.Primary {
+						userInfo.Email = email.Email
+						userInfo.EmailVerified = true
+						break
 					}
 				}
 			}
@@ -140,89 +270,165 @@ func (s *SocialService) GetGitHubUserInfo(token *oauth2.Token) (*GitHubUserInfo,
 	return &userInfo, nil
 }
 
-// FindOrCreateUser finds an existing user or creates a new one
-func (s *SocialService) FindOrCreateUser(provider, providerID, email, name, avatar string, isVerified bool) (*models.User, error) {
-	var user models.User
+// CompleteLogin resolves the user of a finished social login.
+// Logins started by a signed in user to link an account add the identity to that user instead.
+func (s *SocialService) CompleteLogin(pending *OAuthState, profile *SocialProfile) (*models.User, error) {
+	if pending.LinkUserID != 0 {
+		return NewIdentityService(s.server).Link(pending.LinkUserID, profile)
+	}
+	return s.FindOrCreateUser(profile)
+}
 
-	// Try to find user by provider ID first
-	err := s.db.Where("provider = ? AND provider_id = ?", provider, providerID).First(&user).Error
+// FindOrCreateUser finds the user of a linked identity or creates a new user for it.
+// An existing user with the same email is never linked automatically, whoever controls an account with that
+// email at the provider would take over the user. The user has to log in and link the account instead.
+func (s *SocialService) FindOrCreateUser(profile *SocialProfile) (*models.User, error) {
+	identities := NewIdentityService(s.server)
+	identity, user, err := identities.Find(profile.Provider, profile.Subject)
 	if err == nil {
-		// User found, update information
-		user.Name = name
-		user.Email = email
-		user.Avatar = avatar
-		user.IsVerified = isVerified
-		s.db.Save(&user)
-		return &user, nil
+		identities.Touch(identity, profile.Email)
+		updates := map[string]interface{}{}
+		if profile.Avatar != "" && profile.Avatar != user.Avatar {
+			updates["avatar"] = profile.Avatar
+		}
+		if profile.EmailVerified && !user.IsVerified && strings.EqualFold(profile.Email, user.Email) {
+			updates["is_verified"] = true
+		}
+		if len(updates) > 0 {
+			s.db.Model(user).Updates(updates)
+			invalidateCachedUser(s.server, user)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
 	}
 
-	// If not found by provider ID, try to find by email
-	err = s.db.Where("email = ?", email).First(&user).Error
-	if err == nil {
-		// User exists with this email, link the social account
-		user.Provider = provider
-		user.ProviderID = providerID
-		user.Avatar = avatar
-		user.IsVerified = isVerified
-		s.db.Save(&user)
-		return &user, nil
-	}
-
-	// Find the System domain to assign to new users
-	var systemDomain models.Domain
-	err = s.db.Where("name = ?", "System").First(&systemDomain).Error
+	var existing int64
+	s.db.Model(&models.User{}).Where("LOWER(email) = ?", strings.ToLower(profile.Email)).Count(&existing)
+	if existing > 0 {
+		return nil, api.USER_EXISTS("An account with this email exists, log in and link your " + profile.Provider + " account from your profile")
+	}
+
+	domain, role, err := s.resolveSignupTarget(profile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find System domain: %w", err)
+		return nil, err
 	}
 
 	// Create new user
-	user = models.User{
-		Email:      email,
-		Name:       name,
-		Avatar:     avatar,
-		Provider:   provider,
-		ProviderID: providerID,
-		IsVerified: isVerified,
+	user = &models.User{
+		Email:      profile.Email,
+		Name:       profile.Name,
+		Avatar:     profile.Avatar,
+		Provider:   profile.Provider,
+		ProviderID: profile.Subject,
+		IsVerified: profile.EmailVerified,
 		Password:   "", // No password for social login users
-		Domains:    []*models.Domain{&systemDomain},
+	}
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		// Create the domain-user relationship
+		if err := tx.Create(&models.DomainUser{UserID: user.ID, DomainID: domain.ID, Active: true}).Error; err != nil {
+			return fmt.Errorf("failed to create domain-user relationship: %w", err)
+		}
+		identity := &models.Identity{UserID: user.ID, Provider: profile.Provider, Subject: profile.Subject, Email: profile.Email, LastUsedAt: &now}
+		if err := tx.Create(identity).Error; err != nil {
+			return fmt.Errorf("failed to create identity: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.db.Create(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	// Granted after the commit, the Casbin adapter writes through its own connection
+	if role != "" {
+		if _, err := s.server.Casbin.AddRoleForUserInDomain(user.UUID.String(), role, domain.UUID.String()); err != nil {
+			log.Error().Str("event", "role_assignment_failed").Str("provider", profile.Provider).Str("email", profile.Email).Str("role", role).Str("domain", domain.Name).Err(err).Msg("Failed to assign role to new user")
+		} else {
+			log.Info().Str("event", "social_user_role_assigned").Str("provider", profile.Provider).Str("email", profile.Email).Str("role", role).Str("domain", domain.Name).Msg("User assigned to role")
+		}
 	}
 
-	// Create the domain-user relationship
-	domainUser := models.DomainUser{
-		UserID:   user.ID,
-		DomainID: systemDomain.ID,
-		Active:   true,
+	log.Info().Str("event", "social_user_created").Str("provider", profile.Provider).Str("email", profile.Email).Uint64("user_id", uint64(user.ID)).Msg("New user created via social login")
+	return user, nil
+}
+
+// signupTarget picks where a new user lands: the first rule matching the domain of the email, the provider's target
+// or the default. Rules only apply to emails the provider verified, anyone could claim an unverified one.
+func (s *SocialService) signupTarget(profile *SocialProfile) config.SignupTarget {
+	signup := s.server.Config.Auth.Social.Signup
+	target := signup.Default
+	if provider, ok := signup.Providers[profile.Provider]; ok {
+		target = withSignupDefaults(provider, target)
 	}
-	if err := s.db.Create(&domainUser).Error; err != nil {
-		return nil, fmt.Errorf("failed to create domain-user relationship: %w", err)
+	if !profile.EmailVerified {
+		return target
 	}
 
-	// Find the Operator role in the System domain
-	var operatorRole models.Role
-	err = s.db.Where("name = ? AND domain_id = ?", "Operator", systemDomain.ID).First(&operatorRole).Error
-	if err != nil {
-		log.Warn().Str("event", "role_assignment_failed").Str("provider", provider).Str("email", email).Msg("Failed to find Operator role for System domain")
-	} else {
-		// Assign the user to the Operator role in the System domain
-		// Note: This would require Casbin integration which is beyond the scope of this fix
-		// For now, we'll just log that the user was created successfully
-		log.Info().Str("event", "social_user_role_assigned").Str("provider", provider).Str("email", email).Str("role", "Operator").Str("domain", "System").Msg("User assigned to Operator role in System domain")
+	_, emailDomain, _ := strings.Cut(strings.ToLower(profile.Email), "@")
+	for _, rule := range signup.Rules {
+		if rule.EmailDomain == emailDomain {
+			return withSignupDefaults(rule.Target, target)
+		}
 	}
+	return target
+}
 
-	log.Info().Str("event", "social_user_created").Str("provider", provider).Str("email", email).Uint64("user_id", uint64(user.ID)).Msg("New user created via social login")
-	return &user, nil
+// resolveSignupTarget looks up the domain and role a new user gets. A target naming an unknown domain
+// falls back to the default, a role not defined in the domain is not granted.
+func (s *SocialService) resolveSignupTarget(profile *SocialProfile) (*models.Domain, string, error) {
+	target := s.signupTarget(profile)
+	domain := new(models.Domain)
+	if err := s.db.Where("name = ?", target.Domain).First(domain).Error; err != nil {
+		fallback := s.server.Config.Auth.Social.Signup.Default
+		log.Warn().Str("event", "signup_domain_not_found").Str("provider", profile.Provider).Str("domain", target.Domain).Str("fallback", fallback.Domain).Msg("Sign-up domain not found, using the default")
+		target = fallback
+		if err := s.db.Where("name = ?", target.Domain).First(domain).Error; err != nil {
+			return nil, "", fmt.Errorf("failed to find %s domain: %w", target.Domain, err)
+		}
+	}
+
+	if !s.roleDefined(target.Role, domain) {
+		log.Warn().Str("event", "role_assignment_failed").Str("provider", profile.Provider).Str("email", profile.Email).Str("role", target.Role).Str("domain", domain.Name).Msg("Sign-up role not defined in domain")
+		return domain, "", nil
+	}
+	return domain, target.Role, nil
 }
 
-// HandleGoogleLogin processes Google OAuth login
-func (s *SocialService) HandleGoogleLogin(code string) (*models.User, error) {
+// roleDefined reports whether the role exists in the domain, either as role record of the domain or the
+// System domain or through its policies
+func (s *SocialService) roleDefined(role string, domain *models.Domain) bool {
+	if role == "" {
+		return false
+	}
+	if NewRoleService(s.server).RoleExistsInDomain(role, domain) {
+		return true
+	}
+	policies, err := s.server.Casbin.GetFilteredPolicy(0, role, domain.UUID.String())
+	return err == nil && len(policies) > 0
+}
+
+// withSignupDefaults fills the empty fields of target from defaults
+func withSignupDefaults(target, defaults config.SignupTarget) config.SignupTarget {
+	if target.Domain == "" {
+		target.Domain = defaults.Domain
+	}
+	if target.Role == "" {
+		target.Role = defaults.Role
+	}
+	return target
+}
+
+// HandleGoogleLogin processes Google OAuth login, verifying the code with the PKCE verifier of the login's state
+func (s *SocialService) HandleGoogleLogin(code string, pending *OAuthState) (*models.User, error) {
 	config := s.GetGoogleOAuthConfig()
 
 	// Exchange code for token
-	token, err := config.Exchange(context.Background(), code)
+	token, err := config.Exchange(context.Background(), code, oauth2.VerifierOption(pending.Verifier))
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
 	}
@@ -233,28 +439,22 @@ func (s *SocialService) HandleGoogleLogin(code string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get Google user info: %w", err)
 	}
 
-	// Find or create user
-	user, err := s.FindOrCreateUser(
-		"google",
-		userInfo.ID,
-		userInfo.Email,
-		userInfo.Name,
-		userInfo.Picture,
-		userInfo.VerifiedEmail,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find or create user: %w", err)
-	}
-
-	return user, nil
+	return s.CompleteLogin(pending, &SocialProfile{
+		Provider:      "google",
+		Subject:       userInfo.ID,
+		Email:         userInfo.Email,
+		Name:          userInfo.Name,
+		Avatar:        userInfo.Picture,
+		EmailVerified: userInfo.VerifiedEmail,
+	})
 }
 
-// HandleGitHubLogin processes GitHub OAuth login
-func (s *SocialService) HandleGitHubLogin(code string) (*models.User, error) {
+// HandleGitHubLogin processes GitHub OAuth login, verifying the code with the PKCE verifier of the login's state
+func (s *SocialService) HandleGitHubLogin(code string, pending *OAuthState) (*models.User, error) {
 	config := s.GetGitHubOAuthConfig()
 
 	// Exchange code for token
-	token, err := config.Exchange(context.Background(), code)
+	token, err := config.Exchange(context.Background(), code, oauth2.VerifierOption(pending.Verifier))
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
 	}
@@ -264,19 +464,72 @@ func (s *SocialService) HandleGitHubLogin(code string) (*models.User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get GitHub user info: %w", err)
 	}
+	if userInfo.Email == "" {
+		return nil, fmt.Errorf("GitHub did not share a verified email address")
+	}
 
-	// Find or create user
-	user, err := s.FindOrCreateUser(
-		"github",
-		fmt.Sprintf("%d", userInfo.ID),
-		userInfo.Email,
-		userInfo.Name,
-		userInfo.AvatarURL,
-		true, // GitHub emails are typically verified
-	)
+	return s.CompleteLogin(pending, &SocialProfile{
+		Provider:      "github",
+		Subject:       fmt.Sprintf("%d", userInfo.ID),
+		Email:         userInfo.Email,
+		Name:          userInfo.Name,
+		Avatar:        userInfo.AvatarURL,
+		EmailVerified: userInfo.EmailVerified,
+	})
+}
+
+// HandleOIDCLogin processes the login of a generic OpenID Connect provider.
+// The user is identified by the subject of the verified ID token, the email is taken from the userinfo endpoint when the token lacks it.
+func (s *SocialService) HandleOIDCLogin(provider *OIDCProvider, code string, pending *OAuthState) (*models.User, error) {
+	ctx := context.Background()
+	config, err := provider.OAuthConfig(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find or create user: %w", err)
+		return nil, err
 	}
 
-	return user, nil
+	// Exchange code for token
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(pending.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("token response of %s contains no ID token", provider.Name())
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Email == "" {
+		userinfo, err := provider.Userinfo(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		// Userinfo claims only belong to the ID token's user when the subjects match
+		if userinfo.Subject != claims.Subject {
+			return nil, fmt.Errorf("userinfo of %s belongs to another subject", provider.Name())
+		}
+		claims.Email, claims.EmailVerified = userinfo.Email, userinfo.EmailVerified
+		if claims.Name == "" {
+			claims.Name = userinfo.Name
+		}
+	}
+	if claims.Email == "" {
+		return nil, fmt.Errorf("%s did not share an email address", provider.Name())
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+
+	return s.CompleteLogin(pending, &SocialProfile{
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		Name:          name,
+		Avatar:        claims.Picture,
+		EmailVerified: bool(claims.EmailVerified),
+	})
 }
@@ -2,9 +2,9 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"goweb/api"
+	"goweb/keys"
 	"goweb/models"
 	"goweb/server"
 	"time"
@@ -18,15 +18,20 @@ const ExpireAccessMinutes = 30
 const ExpireRefreshMinutes = 2 * 60
 const AutoLogoffMinutes = 10
 
-const TokenUserCacheKey = "tokens:user:%d"
+const TokenFamilyUsedKey = "tokens:family:%s:used"
 
 type Domain struct {
 	UUID string
 	Name string
 }
 
+// JwtCustomClaims are the claims of access and refresh tokens.
+// Family identifies the session: every token rotated from one login shares it.
+// MFA records whether the login was completed with a second factor.
 type JwtCustomClaims struct {
 	UUID     string   `json:"uuid"`
+	Family   string   `json:"family"`
+	MFA      bool     `json:"mfa"`
 	UserID   uint64   `json:"userid"`
 	UserUUID string   `json:"useruuid"`
 	UserName string   `json:"username"`
@@ -34,56 +39,118 @@ type JwtCustomClaims struct {
 	jwt.RegisteredClaims
 }
 
-type CachedTokens struct {
-	AccessUID  string `json:"access"`
-	RefreshUID string `json:"refresh"`
-}
-
 type TokenService struct {
-	server *server.Server
+	server         *server.Server
+	sessionService *SessionService
+	auditService   *AuditService
 }
 
 func NewTokenService(server *server.Server) *TokenService {
 	return &TokenService{
-		server: server,
+		server:         server,
+		sessionService: NewSessionService(server),
+		auditService:   NewAuditService(),
 	}
 }
 
-func (tokenService *TokenService) GenerateTokenPair(user *models.User) (accessToken, refreshToken string, exp int64, err error) {
-	if !domainsLoaded(user) {
-		tokenService.server.DB.Preload("Domains").First(user)
-	}
-
+// GenerateTokenPair starts a new session for the client and issues its first token pair.
+// mfa marks whether the user passed a second factor for this login.
+func (tokenService *TokenService) GenerateTokenPair(user *models.User, client ClientInfo, mfa bool) (accessToken, refreshToken string, exp int64, err error) {
 	var accessUID, refreshUID string
+	family := uuid.New().String()
 
-	if accessToken, accessUID, exp, err = tokenService.createToken(user, ExpireAccessMinutes,
-		tokenService.server.Config.Auth.AccessSecret); err != nil {
+	if accessToken, accessUID, refreshToken, refreshUID, exp, err = tokenService.generateTokenPair(user, family, mfa); err != nil {
 		return
 	}
 
-	if refreshToken, refreshUID, _, err = tokenService.createToken(user, ExpireRefreshMinutes,
-		tokenService.server.Config.Auth.RefreshSecret); err != nil {
-		return
-	}
-
-	cacheJSON, err := json.Marshal(CachedTokens{
+	err = tokenService.sessionService.Create(&Session{
+		ID:         family,
+		UserID:     user.ID,
 		AccessUID:  accessUID,
 		RefreshUID: refreshUID,
+		Device:     client.Device,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
 	})
 
-	tokenService.server.Redis.Set(context.Background(), fmt.Sprintf(TokenUserCacheKey, user.ID), string(cacheJSON), time.Minute*AutoLogoffMinutes)
-
 	return
 }
 
-func (tokenService *TokenService) ParseToken(tokenString, secret string) (claims *JwtCustomClaims, err error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JwtCustomClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secret), nil
+// RotateTokenPair exchanges a refresh token for a new token pair within the same family.
+// Every refresh token can be exchanged only once, presenting an already rotated
+// refresh token again revokes the whole family.
+func (tokenService *TokenService) RotateTokenPair(claims *JwtCustomClaims, client ClientInfo) (user *models.User, accessToken, refreshToken string, exp int64, err error) {
+	ctx := context.Background()
+	usedKey := fmt.Sprintf(TokenFamilyUsedKey, claims.Family)
+
+	added, err := tokenService.server.Redis.SAdd(ctx, usedKey, claims.UUID).Result()
+	if err != nil {
+		return nil, "", "", 0, api.INTERNAL_SERVICE_ERROR("Failed to rotate refresh token")
+	}
+	tokenService.server.Redis.Expire(ctx, usedKey, time.Minute*ExpireRefreshMinutes)
+
+	if added == 0 {
+		tokenService.RevokeSession(claims.UserID, claims.Family)
+		tokenService.auditService.Record(AuditEvent{
+			Event:  "refresh_token_reuse_detected",
+			UserID: claims.UserID,
+			Details: map[string]interface{}{
+				"family":    claims.Family,
+				"token_uid": claims.UUID,
+			},
 		})
+		return nil, "", "", 0, api.INVALID_TOKEN("Refresh token reuse detected")
+	}
+
+	if user, err = tokenService.ValidateToken(claims, true); err != nil {
+		return nil, "", "", 0, err
+	}
+
+	var accessUID, refreshUID string
+	if accessToken, accessUID, refreshToken, refreshUID, exp, err = tokenService.generateTokenPair(user, claims.Family, claims.MFA); err != nil {
+		return nil, "", "", 0, err
+	}
+
+	if err = tokenService.sessionService.Rotate(claims.UserID, claims.Family, accessUID, refreshUID, client); err != nil {
+		return nil, "", "", 0, api.INTERNAL_SERVICE_ERROR("Failed to rotate refresh token")
+	}
+
+	return
+}
+
+// RevokeSession invalidates every token issued within the given session
+func (tokenService *TokenService) RevokeSession(userID uint64, sessionID string) error {
+	return tokenService.sessionService.Revoke(userID, sessionID)
+}
+
+// RevokeAllSessions invalidates every token of the user on all devices
+func (tokenService *TokenService) RevokeAllSessions(userID uint64) error {
+	return tokenService.sessionService.RevokeAll(userID)
+}
+
+// TouchSession records activity on the session the claims belong to
+func (tokenService *TokenService) TouchSession(claims *JwtCustomClaims, ip string) error {
+	return tokenService.sessionService.Touch(claims.UserID, claims.Family, ip)
+}
+
+func (tokenService *TokenService) generateTokenPair(user *models.User, family string, mfa bool) (accessToken, accessUID, refreshToken, refreshUID string, exp int64, err error) {
+	if !domainsLoaded(user) {
+		tokenService.server.DB.Preload("Domains").First(user)
+	}
+
+	if accessToken, accessUID, exp, err = tokenService.createToken(user, family, mfa, ExpireAccessMinutes,
+		tokenService.server.AccessKeys); err != nil {
+		return
+	}
+
+	refreshToken, refreshUID, _, err = tokenService.createToken(user, family, mfa, ExpireRefreshMinutes,
+		tokenService.server.RefreshKeys)
+
+	return
+}
+
+func (tokenService *TokenService) ParseToken(tokenString string, keyRing *keys.KeyRing) (claims *JwtCustomClaims, err error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JwtCustomClaims{}, keyRing.Keyfunc)
 	if err != nil {
 		return
 	}
@@ -99,18 +166,17 @@ func (tokenService *TokenService) ValidateToken(claims *JwtCustomClaims, isRefre
 	var g errgroup.Group
 
 	g.Go(func() error {
-		cacheJSON, _ := tokenService.server.Redis.Get(context.Background(), fmt.Sprintf(TokenUserCacheKey, claims.UserID)).Result()
-		cachedTokens := new(CachedTokens)
-		err = json.Unmarshal([]byte(cacheJSON), cachedTokens)
+		session := new(Session)
+		if err := tokenService.sessionService.Get(session, claims.Family); err != nil || session.UserID != claims.UserID {
+			return api.TOKEN_EXPIRED()
+		}
 
-		var tokenUID string
+		tokenUID := session.AccessUID
 		if isRefresh {
-			tokenUID = cachedTokens.RefreshUID
-		} else {
-			tokenUID = cachedTokens.AccessUID
+			tokenUID = session.RefreshUID
 		}
 
-		if err != nil || tokenUID != claims.UUID {
+		if tokenUID != claims.UUID {
 			return api.TOKEN_EXPIRED()
 		}
 
@@ -131,7 +197,7 @@ func (tokenService *TokenService) ValidateToken(claims *JwtCustomClaims, isRefre
 	return user, err
 }
 
-func (tokenService *TokenService) createToken(user *models.User, expireMinutes int, secret string) (token, tokenUuid string, exp int64, err error) {
+func (tokenService *TokenService) createToken(user *models.User, family string, mfa bool, expireMinutes int, keyRing *keys.KeyRing) (token, tokenUuid string, exp int64, err error) {
 	expiry := time.Now().Add(time.Minute * time.Duration(expireMinutes))
 	tokenUuid = uuid.New().String()
 
@@ -146,6 +212,8 @@ func (tokenService *TokenService) createToken(user *models.User, expireMinutes i
 
 	claims := &JwtCustomClaims{
 		tokenUuid,
+		family,
+		mfa,
 		user.ID,
 		user.UUID.String(),
 		user.Name,
@@ -156,8 +224,7 @@ func (tokenService *TokenService) createToken(user *models.User, expireMinutes i
 		},
 	}
 	exp = expiry.Unix()
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-	token, err = jwtToken.SignedString([]byte(secret))
+	token, err = keyRing.Sign(claims)
 
 	return
 }
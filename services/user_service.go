@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -75,6 +76,13 @@ func (service *UserService) setCachedUser(key string, user *models.User) error {
 	return service.Redis.Redis.Set(ctx, key, data, CacheTTL).Err()
 }
 
+// invalidateCachedUser drops cached copies of a user changed outside of UserService
+func invalidateCachedUser(server *server.Server, user *models.User) {
+	userService := NewUserService(server.DB)
+	userService.SetRedis(server)
+	userService.invalidateUserCache(user.ID, user.Email, user.UUID.String())
+}
+
 // invalidateUserCache removes user from cache
 func (service *UserService) invalidateUserCache(userID uint64, email, uuid string) {
 	if service.Redis == nil {
@@ -96,7 +104,7 @@ func (service *UserService) invalidateUserCache(userID uint64, email, uuid strin
 func (service *UserService) Register(e echo.Context, request *requests.RegisterRequest, domain *models.Domain) error {
 	user := models.User{}
 
-	service.GetUserByEmail(&user, request.Email)
+	service.DB.Where("email = ?", request.Email).First(&user)
 
 	if user.ID != 0 {
 		return api.WebResponse(e, http.StatusBadRequest, api.USER_EXISTS())
@@ -123,7 +131,14 @@ func (service *UserService) Register(e echo.Context, request *requests.RegisterR
 
 	service.DB.Save(&models.DomainUser{UserID: newUser.ID, DomainID: domain.ID, Active: true})
 
-	return api.WebResponse(e, http.StatusCreated, api.RESOURCE_CREATED("User created"))
+	// Password sign-ups prove ownership of the email with a signed link
+	if service.Redis != nil {
+		if err := NewVerificationService(service.Redis).SendVerification(&newUser); err != nil {
+			log.Error().Str("event", "verification_email_failed").Err(err).Uint64("user_id", newUser.ID).Msg("Failed to send verification email")
+		}
+	}
+
+	return api.WebResponse(e, http.StatusCreated, api.RESOURCE_CREATED("User created, check your email to verify it"))
 }
 
 func (service *UserService) UpdateUser(user *models.User) error {
@@ -223,6 +238,14 @@ func (service *UserService) GetUserByUuidInDomain(user *models.User, uuid string
 		First(user).Error
 }
 
+func (service *UserService) GetUserByIdInDomain(user *models.User, id uint64, domain *models.Domain) error {
+	return service.DB.
+		Joins("JOIN domain_users ON domain_users.user_id = users.id").
+		Where("domain_users.domain_id = ?", domain.ID).
+		Where("users.id = ?", id).
+		First(user).Error
+}
+
 func (service *UserService) DeleteUserByUuidInDomain(user *models.User, uuid string, domain *models.Domain) error {
 	// delete the user only if User.uuid == uuid & User.domains contains selected domain
 	// get the domains of the user that we want to delete
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"goweb/api"
+	"goweb/mailer"
+	"goweb/models"
+	"goweb/server"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// VerificationResendCacheKey blocks another verification email to the address until it expires
+	VerificationResendCacheKey = "verify:resend:%s"
+	// VerificationResendIPCacheKey counts the verification emails requested from an IP
+	VerificationResendIPCacheKey = "verify:resend:ip:%s"
+)
+
+// VerificationAudience keeps verification tokens from being accepted as access or refresh tokens and vice versa
+const VerificationAudience = "email-verification"
+
+const VerificationExpireHours = 24
+const VerificationResendSeconds = 60
+const VerificationResendPerIPHour = 10
+
+// EmailVerificationClaims bind a verification link to the user and the address it was sent to,
+// so a link stops working once the email changes
+type EmailVerificationClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+type VerificationService struct {
+	server *server.Server
+}
+
+func NewVerificationService(server *server.Server) *VerificationService {
+	return &VerificationService{server: server}
+}
+
+// SendVerification mails a signed verification link to the user
+func (service *VerificationService) SendVerification(user *models.User) error {
+	now := time.Now()
+	token, err := service.server.RefreshKeys.Sign(&EmailVerificationClaims{
+		Email: user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.UUID.String(),
+			Audience:  jwt.ClaimStrings{VerificationAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour * VerificationExpireHours)),
+		},
+	})
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to sign verification token")
+	}
+
+	link := service.server.Config.HTTP.FrontendURL + "/verify-email?token=" + url.QueryEscape(token)
+	err = service.server.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email address with the link below, it is valid for %d hours:\n\n%s\n",
+			user.Name, VerificationExpireHours, link),
+	})
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to send verification email")
+	}
+	return nil
+}
+
+// Verify checks the signed token and marks the email of its user as verified
+func (service *VerificationService) Verify(token string) (*models.User, error) {
+	claims := new(EmailVerificationClaims)
+	_, err := jwt.ParseWithClaims(token, claims, service.server.RefreshKeys.Keyfunc,
+		jwt.WithAudience(VerificationAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, api.INVALID_TOKEN("Invalid or expired verification link")
+	}
+
+	user := new(models.User)
+	if err := service.server.DB.Where("uuid = ?", claims.Subject).First(user).Error; err != nil {
+		return nil, api.USER_NOT_FOUND()
+	}
+	if !strings.EqualFold(user.Email, claims.Email) {
+		return nil, api.INVALID_TOKEN("Verification link was sent to a previous email address")
+	}
+	if user.IsVerified {
+		return user, nil
+	}
+
+	if err := service.server.DB.Model(user).Update("is_verified", true).Error; err != nil {
+		return nil, api.INTERNAL_SERVICE_ERROR("Failed to verify email")
+	}
+	invalidateCachedUser(service.server, user)
+	return user, nil
+}
+
+// Resend mails a new verification link, limited per address and per IP.
+// Unknown and already verified addresses are not reported, so the endpoint does not reveal which accounts exist.
+func (service *VerificationService) Resend(email, ip string) error {
+	ctx := context.Background()
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	ipKey := fmt.Sprintf(VerificationResendIPCacheKey, ip)
+	requests, err := service.server.Redis.Incr(ctx, ipKey).Result()
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to send verification email")
+	}
+	if requests == 1 {
+		service.server.Redis.Expire(ctx, ipKey, time.Hour)
+	}
+	if requests > VerificationResendPerIPHour {
+		return api.TOO_MANY_REQUESTS("Too many verification emails requested, try again later")
+	}
+
+	allowed, err := service.server.Redis.SetNX(ctx, fmt.Sprintf(VerificationResendCacheKey, email), 1,
+		time.Second*VerificationResendSeconds).Result()
+	if err != nil {
+		return api.INTERNAL_SERVICE_ERROR("Failed to send verification email")
+	}
+	if !allowed {
+		return api.TOO_MANY_REQUESTS(fmt.Sprintf("A verification email was just sent, wait %d seconds before requesting another", VerificationResendSeconds))
+	}
+
+	user := new(models.User)
+	if err := service.server.DB.Where("LOWER(email) = ?", email).First(user).Error; err != nil || user.IsVerified {
+		return nil
+	}
+	return service.SendVerification(user)
+}
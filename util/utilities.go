@@ -60,6 +60,15 @@ func ExtractDomain(e echo.Context) (interface{}, error) {
 	return domain, nil
 }
 
+// Extracts the Casbin subject from echo.Context: the user or the API key the request is authenticated as
+func ExtractSubject(e echo.Context) (string, error) {
+	subject, ok := e.Get("subject").(string)
+	if !ok || subject == "" {
+		return "", echo.NewHTTPError(http.StatusUnauthorized, "Invalid subject context")
+	}
+	return subject, nil
+}
+
 // Checks if user domains are loaded
 func DomainsLoaded(user interface{ GetDomains() []interface{} }) bool {
 	domains := user.GetDomains()